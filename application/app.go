@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/auth"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/daniel-vuky/golang-todo-list-v2/storage"
 	"github.com/gin-gonic/gin"
 	_ "github.com/go-sql-driver/mysql"
 	"log"
@@ -15,6 +18,7 @@ import (
 type App struct {
 	router *gin.Engine
 	rdb    *sql.DB
+	blobs  storage.BlobStore
 }
 
 // New create new application
@@ -34,8 +38,17 @@ func New() *App {
 		log.Fatalf(fmt.Sprintf("Can not connect to mysql server, %s", connectedErr.Error()))
 	}
 
+	blobs, storageErr := storage.New()
+	if storageErr != nil {
+		log.Fatalf(fmt.Sprintf("Can not create the blob storage, %s", storageErr.Error()))
+	}
+
+	// The access tokens are checked against the revoked tokens and the token version of their user
+	auth.UseRevocationStore(repository.TokenRevocationsRepository{Db: db})
+
 	app := &App{
-		rdb: db,
+		rdb:   db,
+		blobs: blobs,
 	}
 
 	app.LoadRoutes()
@@ -64,6 +77,7 @@ func (app *App) Start(ctx context.Context) error {
 	}()
 
 	fmt.Println("Starting server")
+	app.startJobs(ctx)
 
 	serverError := make(chan error, 1)
 	go func() {
@@ -82,6 +96,4 @@ func (app *App) Start(ctx context.Context) error {
 
 		return server.Shutdown(timeout)
 	}
-
-	return nil
 }
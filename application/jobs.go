@@ -0,0 +1,112 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"os"
+	"strconv"
+	"time"
+)
+
+const DefaultTrashRetentionDays int = 30
+const DefaultAutoArchiveDays int = 30
+const JobInterval time.Duration = time.Hour
+
+// startJobs run the background jobs until the context is cancelled
+func (app *App) startJobs(ctx context.Context) {
+	go runEvery(ctx, JobInterval, app.purgeTrash)
+	go runEvery(ctx, JobInterval, app.purgeExpiredTokens)
+	if days := envDays("AUTO_ARCHIVE_DAYS", DefaultAutoArchiveDays); days > 0 {
+		go runEvery(ctx, JobInterval, app.archiveCompleted)
+	}
+}
+
+// runEvery run the job now, then at every interval until the context is cancelled
+// The job gets the context, so a shutdown cancels its calls in flight
+func runEvery(ctx context.Context, interval time.Duration, job func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		job(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeTrash delete for good the items kept in the trash for more than TRASH_RETENTION_DAYS,
+// then the blobs of the attachments detached from their item and not purged by the requests
+func (app *App) purgeTrash(ctx context.Context) {
+	itemsRepository := repository.ItemsRepository{Db: app.rdb}
+	days := envDays("TRASH_RETENTION_DAYS", DefaultTrashRetentionDays)
+	if days <= 0 {
+		days = DefaultTrashRetentionDays
+	}
+	before := time.Now().UTC().AddDate(0, 0, -days).Format(model.DatabaseTimeLayout)
+	purged, purgeErr := itemsRepository.PurgeTrash(before)
+	if purgeErr != nil {
+		fmt.Printf("Fail to purge the trash, %s\n", purgeErr.Error())
+	} else if purged > 0 {
+		fmt.Printf("Purged %d items from the trash\n", purged)
+	}
+	attachmentsRepository := repository.AttachmentsRepository{Db: app.rdb}
+	// A failure is only logged, the blobs left behind are purged by the next run
+	purgedAttachments, purgeAttachmentsErr := attachmentsRepository.PurgeDetached(ctx, app.blobs)
+	if purgeAttachmentsErr != nil {
+		fmt.Printf("Fail to purge the attachments, %s\n", purgeAttachmentsErr.Error())
+	} else if purgedAttachments > 0 {
+		fmt.Printf("Purged %d attachments\n", purgedAttachments)
+	}
+}
+
+// archiveCompleted archive the items completed more than AUTO_ARCHIVE_DAYS ago
+func (app *App) archiveCompleted(context.Context) {
+	itemsRepository := repository.ItemsRepository{Db: app.rdb}
+	days := envDays("AUTO_ARCHIVE_DAYS", DefaultAutoArchiveDays)
+	before := time.Now().UTC().AddDate(0, 0, -days).Format(model.DatabaseTimeLayout)
+	archived, archiveErr := itemsRepository.ArchiveCompleted(before)
+	if archiveErr != nil {
+		fmt.Printf("Fail to archive the completed items, %s\n", archiveErr.Error())
+	} else if archived > 0 {
+		fmt.Printf("Archived %d completed items\n", archived)
+	}
+}
+
+// purgeExpiredTokens delete the expired refresh tokens with their login sessions,
+// and the revocations of the expired access tokens
+func (app *App) purgeExpiredTokens(context.Context) {
+	refreshTokensRepository := repository.RefreshTokensRepository{Db: app.rdb}
+	purged, purgeErr := refreshTokensRepository.PurgeExpired()
+	if purgeErr != nil {
+		fmt.Printf("Fail to purge the refresh tokens, %s\n", purgeErr.Error())
+	} else if purged > 0 {
+		fmt.Printf("Purged %d expired refresh tokens\n", purged)
+	}
+	tokenRevocationsRepository := repository.TokenRevocationsRepository{Db: app.rdb}
+	purged, purgeErr = tokenRevocationsRepository.PurgeExpired()
+	if purgeErr != nil {
+		fmt.Printf("Fail to purge the revoked tokens, %s\n", purgeErr.Error())
+	} else if purged > 0 {
+		fmt.Printf("Purged %d expired revoked tokens\n", purged)
+	}
+	loginSessionsRepository := repository.LoginSessionsRepository{Db: app.rdb}
+	purged, purgeErr = loginSessionsRepository.PurgeExpired()
+	if purgeErr != nil {
+		fmt.Printf("Fail to purge the login sessions, %s\n", purgeErr.Error())
+	} else if purged > 0 {
+		fmt.Printf("Purged %d expired login sessions\n", purged)
+	}
+}
+
+// envDays read a number of days from the environment, fallback when it is not set
+func envDays(name string, fallback int) int {
+	days, daysErr := strconv.Atoi(os.Getenv(name))
+	if daysErr != nil {
+		return fallback
+	}
+	return days
+}
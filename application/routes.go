@@ -2,6 +2,7 @@ package application
 
 import (
 	"github.com/daniel-vuky/golang-todo-list-v2/handler"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
 	"github.com/daniel-vuky/golang-todo-list-v2/repository"
 	"github.com/gin-gonic/gin"
 	ginSession "github.com/go-session/gin-session"
@@ -12,6 +13,7 @@ import (
 // LoadRoutes load all the routes of application
 func (app *App) LoadRoutes() {
 	router := gin.Default()
+	router.Use(handler.RequestID)
 	router.Use(ginSession.New())
 
 	// Set the HTML templates directory
@@ -25,10 +27,25 @@ func (app *App) LoadRoutes() {
 		Auth: &repository.AuthRepository{
 			Db: app.rdb,
 		},
+		RefreshTokens: &repository.RefreshTokensRepository{
+			Db: app.rdb,
+		},
+		Sessions: &repository.LoginSessionsRepository{
+			Db: app.rdb,
+		},
+		PersonalTokens: &repository.PersonalAccessTokensRepository{
+			Db: app.rdb,
+		},
 	}
 
 	LoadAuthRoutes(app, router, usersHandler)
 	LoadItemRoutes(app, router, usersHandler)
+	LoadListRoutes(app, router, usersHandler)
+	LoadTagRoutes(app, router, usersHandler)
+	LoadCommentRoutes(app, router, usersHandler)
+	LoadAttachmentRoutes(app, router, usersHandler)
+	LoadShareRoutes(app, router, usersHandler)
+	LoadNotificationRoutes(app, router, usersHandler)
 
 	app.router = router
 }
@@ -38,7 +55,8 @@ func LoadAuthRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
 
 	router.GET("/", usersHandler.AuthMiddleware, func(c *gin.Context) {
 		username := usersHandler.GetUsernameFromContext(c)
-		c.HTML(http.StatusOK, "index.html", gin.H{"username": username})
+		timezone := usersHandler.GetTimezoneFromContext(c)
+		c.HTML(http.StatusOK, "index.html", gin.H{"username": username, "timezone": timezone})
 	})
 	router.GET("/login", func(c *gin.Context) {
 		errorCode, _ := strconv.Atoi(c.Query("error"))
@@ -53,8 +71,21 @@ func LoadAuthRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
 		c.HTML(http.StatusOK, "register.html", gin.H{})
 	})
 	router.POST("/login", usersHandler.Login)
+	router.POST("/api/auth/token", usersHandler.Token)
+	router.POST("/auth/refresh", usersHandler.Refresh)
 	router.GET("/logout", usersHandler.Logout)
+	router.POST("/auth/logout-all", usersHandler.AuthMiddleware, usersHandler.LogoutAll)
 	router.POST("/register", usersHandler.Register)
+	router.PUT("/account/timezone", usersHandler.AuthMiddleware, usersHandler.UpdateTimezone)
+	router.GET("/settings", usersHandler.AuthMiddleware, func(c *gin.Context) {
+		username := usersHandler.GetUsernameFromContext(c)
+		c.HTML(http.StatusOK, "settings.html", gin.H{"username": username})
+	})
+	router.GET("/account/sessions", usersHandler.AuthMiddleware, usersHandler.ListSessions)
+	router.DELETE("/account/sessions/:id", usersHandler.AuthMiddleware, usersHandler.DeleteSession)
+	router.POST("/account/tokens", usersHandler.AuthMiddleware, usersHandler.CreatePersonalToken)
+	router.GET("/account/tokens", usersHandler.AuthMiddleware, usersHandler.ListPersonalTokens)
+	router.DELETE("/account/tokens/:id", usersHandler.AuthMiddleware, usersHandler.DeletePersonalToken)
 }
 
 // LoadItemRoutes load all the items api routes
@@ -63,13 +94,174 @@ func LoadItemRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
 		Repository: &repository.ItemsRepository{
 			Db: app.rdb,
 		},
+		Lists: &repository.ListsRepository{
+			Db: app.rdb,
+		},
+		Users: usersHandler.Repository,
+		Attachments: &repository.AttachmentsRepository{
+			Db: app.rdb,
+		},
+		Blobs: app.blobs,
+		Shares: &repository.SharesRepository{
+			Db: app.rdb,
+		},
 	}
+	// The personal access tokens reach the items with their scopes
+	itemsRead := usersHandler.AuthMiddlewareWithScope(model.ScopeItemsRead)
+	itemsWrite := usersHandler.AuthMiddlewareWithScope(model.ScopeItemsWrite)
 	itemGroup := router.Group("/items")
 	{
-		itemGroup.GET("/", usersHandler.AuthMiddleware, itemsHandler.List)
-		itemGroup.POST("/", usersHandler.AuthMiddleware, itemsHandler.Create)
-		itemGroup.GET("/:id", usersHandler.AuthMiddleware, itemsHandler.GetByID)
-		itemGroup.PUT("/:id", usersHandler.AuthMiddleware, itemsHandler.UpdateByID)
-		itemGroup.DELETE("/:id", usersHandler.AuthMiddleware, itemsHandler.DeleteByID)
+		itemGroup.GET("/", itemsRead, itemsHandler.List)
+		itemGroup.GET("/today", itemsRead, itemsHandler.Today)
+		itemGroup.GET("/upcoming", itemsRead, itemsHandler.Upcoming)
+		itemGroup.GET("/overdue", itemsRead, itemsHandler.Overdue)
+		itemGroup.GET("/search", itemsRead, itemsHandler.Search)
+		itemGroup.GET("/trash", itemsRead, itemsHandler.Trash)
+		itemGroup.GET("/archive", itemsRead, itemsHandler.Archived)
+		itemGroup.GET("/shared", itemsRead, itemsHandler.Shared)
+		itemGroup.POST("/", itemsWrite, itemsHandler.Create)
+		itemGroup.POST("/bulk", itemsWrite, itemsHandler.Bulk)
+		itemGroup.GET("/:id", itemsRead, itemsHandler.GetByID)
+		itemGroup.GET("/:id/occurrences", itemsRead, itemsHandler.Occurrences)
+		itemGroup.GET("/:id/history", itemsRead, itemsHandler.History)
+		itemGroup.POST("/:id/revert/:version", itemsWrite, itemsHandler.Revert)
+		itemGroup.POST("/:id/move", itemsWrite, itemsHandler.Move)
+		itemGroup.POST("/:id/restore", itemsWrite, itemsHandler.Restore)
+		itemGroup.POST("/:id/archive", itemsWrite, itemsHandler.Archive)
+		itemGroup.POST("/:id/unarchive", itemsWrite, itemsHandler.Unarchive)
+		itemGroup.PUT("/:id", itemsWrite, itemsHandler.UpdateByID)
+		itemGroup.PATCH("/:id", itemsWrite, itemsHandler.PatchByID)
+		itemGroup.DELETE("/:id", itemsWrite, itemsHandler.DeleteByID)
+	}
+}
+
+// LoadListRoutes load all the lists api routes
+func LoadListRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
+	listsHandler := &handler.Lists{
+		Repository: &repository.ListsRepository{
+			Db: app.rdb,
+		},
+		Items: &repository.ItemsRepository{
+			Db: app.rdb,
+		},
+		Shares: &repository.SharesRepository{
+			Db: app.rdb,
+		},
+	}
+	listGroup := router.Group("/lists")
+	{
+		listGroup.GET("/", usersHandler.AuthMiddleware, listsHandler.List)
+		listGroup.POST("/", usersHandler.AuthMiddleware, listsHandler.Create)
+		listGroup.GET("/:id", usersHandler.AuthMiddleware, listsHandler.GetByID)
+		listGroup.PUT("/:id", usersHandler.AuthMiddleware, listsHandler.UpdateByID)
+		listGroup.DELETE("/:id", usersHandler.AuthMiddleware, listsHandler.DeleteByID)
+		listGroup.GET("/:id/items", usersHandler.AuthMiddleware, listsHandler.ListItems)
+	}
+}
+
+// LoadTagRoutes load all the tags api routes
+func LoadTagRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
+	tagsHandler := &handler.Tags{
+		Repository: &repository.TagsRepository{
+			Db: app.rdb,
+		},
+	}
+	tagGroup := router.Group("/tags")
+	{
+		tagGroup.GET("/", usersHandler.AuthMiddleware, tagsHandler.List)
+		tagGroup.POST("/", usersHandler.AuthMiddleware, tagsHandler.Create)
+		tagGroup.GET("/:id", usersHandler.AuthMiddleware, tagsHandler.GetByID)
+		tagGroup.PUT("/:id", usersHandler.AuthMiddleware, tagsHandler.UpdateByID)
+		tagGroup.DELETE("/:id", usersHandler.AuthMiddleware, tagsHandler.DeleteByID)
+	}
+}
+
+// LoadCommentRoutes load all the comments api routes
+func LoadCommentRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
+	commentsHandler := &handler.Comments{
+		Repository: &repository.CommentsRepository{
+			Db: app.rdb,
+		},
+		Items: &repository.ItemsRepository{
+			Db: app.rdb,
+		},
+		Shares: &repository.SharesRepository{
+			Db: app.rdb,
+		},
+	}
+	// The personal access tokens reach the comments with the scopes of the items
+	itemsRead := usersHandler.AuthMiddlewareWithScope(model.ScopeItemsRead)
+	itemsWrite := usersHandler.AuthMiddlewareWithScope(model.ScopeItemsWrite)
+	commentGroup := router.Group("/items/:id/comments")
+	{
+		commentGroup.GET("/", itemsRead, commentsHandler.List)
+		commentGroup.POST("/", itemsWrite, commentsHandler.Create)
+		commentGroup.PUT("/:comment_id", itemsWrite, commentsHandler.UpdateByID)
+		commentGroup.DELETE("/:comment_id", itemsWrite, commentsHandler.DeleteByID)
+	}
+}
+
+// LoadAttachmentRoutes load all the attachments api routes
+func LoadAttachmentRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
+	attachmentsHandler := &handler.Attachments{
+		Repository: &repository.AttachmentsRepository{
+			Db: app.rdb,
+		},
+		Items: &repository.ItemsRepository{
+			Db: app.rdb,
+		},
+		Blobs: app.blobs,
+		Shares: &repository.SharesRepository{
+			Db: app.rdb,
+		},
+	}
+	// The personal access tokens reach the attachments with the scopes of the items
+	itemsRead := usersHandler.AuthMiddlewareWithScope(model.ScopeItemsRead)
+	itemsWrite := usersHandler.AuthMiddlewareWithScope(model.ScopeItemsWrite)
+	router.GET("/items/:id/attachments", itemsRead, attachmentsHandler.List)
+	router.POST("/items/:id/attachments", itemsWrite, attachmentsHandler.Create)
+	attachmentGroup := router.Group("/attachments")
+	{
+		attachmentGroup.GET("/:id", itemsRead, attachmentsHandler.Download)
+		attachmentGroup.DELETE("/:id", itemsWrite, attachmentsHandler.DeleteByID)
+	}
+}
+
+// LoadShareRoutes load all the shares api routes
+func LoadShareRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
+	sharesHandler := &handler.Shares{
+		Repository: &repository.SharesRepository{
+			Db: app.rdb,
+		},
+		Items: &repository.ItemsRepository{
+			Db: app.rdb,
+		},
+		Lists: &repository.ListsRepository{
+			Db: app.rdb,
+		},
+		Users: usersHandler.Repository,
+	}
+	shareGroup := router.Group("/shares")
+	{
+		shareGroup.GET("/", usersHandler.AuthMiddleware, sharesHandler.List)
+		shareGroup.POST("/", usersHandler.AuthMiddleware, sharesHandler.Create)
+		shareGroup.GET("/invitations", usersHandler.AuthMiddleware, sharesHandler.Invitations)
+		shareGroup.POST("/:id/accept", usersHandler.AuthMiddleware, sharesHandler.Accept)
+		shareGroup.DELETE("/:id", usersHandler.AuthMiddleware, sharesHandler.DeleteByID)
+	}
+}
+
+// LoadNotificationRoutes load all the notifications api routes
+func LoadNotificationRoutes(app *App, router *gin.Engine, usersHandler *handler.Users) {
+	notificationsHandler := &handler.Notifications{
+		Repository: &repository.NotificationsRepository{
+			Db: app.rdb,
+		},
+	}
+	notificationGroup := router.Group("/notifications")
+	{
+		notificationGroup.GET("/", usersHandler.AuthMiddleware, notificationsHandler.List)
+		notificationGroup.POST("/read", usersHandler.AuthMiddleware, notificationsHandler.Read)
+		notificationGroup.POST("/:id/read", usersHandler.AuthMiddleware, notificationsHandler.Read)
 	}
 }
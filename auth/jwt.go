@@ -1,23 +1,46 @@
 package auth
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	jwtGo "github.com/golang-jwt/jwt/v5"
 	"os"
 	"time"
 )
 
-// Create JWT token base on username
-func Create(username string) (string, error) {
+// TokenTypeAccess type of the tokens authenticating the requests
+const TokenTypeAccess string = "access"
+
+// AccessTokenLifetime validity of the access tokens
+const AccessTokenLifetime time.Duration = time.Hour
+
+// RefreshTokenLifetime validity of the refresh tokens, renewed by every rotation
+const RefreshTokenLifetime time.Duration = 30 * 24 * time.Hour
+
+// Create JWT access token for the user
+// The jti claim identifies the token to revoke it, the ver claim is the token version of the user
+// The sid claim is the login session of the token, the family of its refresh tokens
+func Create(userID uint64, username string, version int, sessionID string) (string, error) {
+	tokenID, tokenIDErr := randomHex(16)
+	if tokenIDErr != nil {
+		return "", tokenIDErr
+	}
 	claims := jwtGo.MapClaims{}
 	claims["authorized"] = true
 	claims["user_name"] = username
-	claims["exp"] = time.Now().Add(time.Hour).Unix()
+	claims["user_id"] = userID
+	claims["typ"] = TokenTypeAccess
+	claims["jti"] = tokenID
+	claims["ver"] = version
+	claims["sid"] = sessionID
+	claims["exp"] = time.Now().Add(AccessTokenLifetime).Unix()
 	token := jwtGo.NewWithClaims(jwtGo.SigningMethodHS256, claims)
 	return token.SignedString([]byte(os.Getenv("SECRET_JWT")))
 }
 
 // ValidateToken pass from API
+// The tokens revoked in the revocation store are refused, see UseRevocationStore
 func ValidateToken(tokenString string) (*jwtGo.Token, error) {
 	finalToken, err := jwtGo.Parse(tokenString, func(token *jwtGo.Token) (interface{}, error) {
 		// Check the signing method
@@ -31,9 +54,51 @@ func ValidateToken(tokenString string) (*jwtGo.Token, error) {
 		return nil, err
 	}
 
+	if revocations != nil {
+		claims := tokenClaims(finalToken)
+		if claims.tokenID == "" || claims.userID == 0 {
+			return nil, fmt.Errorf("Token can not be revoked!")
+		}
+		revoked, revokedErr := revocations.Revoked(claims.tokenID, claims.sessionID, claims.userID, claims.version)
+		if revokedErr != nil {
+			return nil, revokedErr
+		}
+		if revoked {
+			return nil, fmt.Errorf("Token revoked!")
+		}
+	}
+
 	return finalToken, nil
 }
 
+// claims identity of a token
+type claims struct {
+	tokenID   string
+	sessionID string
+	userID    uint64
+	version   int
+}
+
+// tokenClaims read the identity of the token, the missing claims are left empty
+// The JSON numbers of the claims are decoded as float64
+func tokenClaims(token *jwtGo.Token) claims {
+	mapClaims, _ := token.Claims.(jwtGo.MapClaims)
+	tokenID, _ := mapClaims["jti"].(string)
+	userID, _ := mapClaims["user_id"].(float64)
+	sessionID, _ := mapClaims["sid"].(string)
+	version, _ := mapClaims["ver"].(float64)
+	return claims{tokenID: tokenID, sessionID: sessionID, userID: uint64(userID), version: int(version)}
+}
+
+// randomHex generate a random hexadecimal string of the size in bytes
+func randomHex(size int) (string, error) {
+	random := make([]byte, size)
+	if _, randomErr := rand.Read(random); randomErr != nil {
+		return "", randomErr
+	}
+	return hex.EncodeToString(random), nil
+}
+
 // GetUsernameFromToken get username from token
 func GetUsernameFromToken(tokenString string) (string, error) {
 	token, tokenErr := ValidateToken(tokenString)
@@ -57,9 +122,41 @@ func GetUserIDFromToken(tokenString string) (uint64, error) {
 		return 0, nil
 	}
 	if claims, ok := token.Claims.(jwtGo.MapClaims); ok && token.Valid {
-		if userID, exists := claims["user_id"]; exists {
-			return userID.(uint64), nil
+		// The JSON numbers of the claims are decoded as float64
+		if userID, exists := claims["user_id"].(float64); exists && userID > 0 {
+			return uint64(userID), nil
 		}
 	}
 	return 0, fmt.Errorf("Invalid Token!")
 }
+
+// AccessClaims user and login session of an access token
+type AccessClaims struct {
+	UserID    uint64
+	SessionID string
+}
+
+// ParseAccessToken validate the access token and get its user and login session
+// The tokens of other types are refused
+func ParseAccessToken(tokenString string) (AccessClaims, error) {
+	token, tokenErr := ValidateToken(tokenString)
+	if tokenErr != nil {
+		return AccessClaims{}, tokenErr
+	}
+	mapClaims, ok := token.Claims.(jwtGo.MapClaims)
+	if !ok || !token.Valid || mapClaims["typ"] != TokenTypeAccess {
+		return AccessClaims{}, fmt.Errorf("Invalid Token!")
+	}
+	claims := tokenClaims(token)
+	if claims.userID == 0 {
+		return AccessClaims{}, fmt.Errorf("Invalid Token!")
+	}
+	return AccessClaims{UserID: claims.userID, SessionID: claims.sessionID}, nil
+}
+
+// GetUserIDFromAccessToken validate the access token and get the ID of its user
+// The tokens of other types are refused
+func GetUserIDFromAccessToken(tokenString string) (uint64, error) {
+	claims, claimsErr := ParseAccessToken(tokenString)
+	return claims.UserID, claimsErr
+}
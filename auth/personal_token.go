@@ -0,0 +1,18 @@
+package auth
+
+// PersonalAccessTokenPrefix start of the personal access tokens, tells them apart from the JWT access tokens
+const PersonalAccessTokenPrefix string = "tdl_pat_"
+
+// NewPersonalAccessToken generate an opaque personal access token, only its hash is stored
+func NewPersonalAccessToken() (string, error) {
+	random, randomErr := NewRefreshToken()
+	if randomErr != nil {
+		return "", randomErr
+	}
+	return PersonalAccessTokenPrefix + random, nil
+}
+
+// HashPersonalAccessToken get the SHA-256 hash of the personal access token stored in the database
+func HashPersonalAccessToken(token string) string {
+	return HashRefreshToken(token)
+}
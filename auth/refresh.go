@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewRefreshToken generate an opaque refresh token, only its hash is stored
+func NewRefreshToken() (string, error) {
+	random := make([]byte, 32)
+	if _, randomErr := rand.Read(random); randomErr != nil {
+		return "", randomErr
+	}
+	return base64.RawURLEncoding.EncodeToString(random), nil
+}
+
+// NewTokenFamily generate the ID shared by a refresh token and all its successors
+func NewTokenFamily() (string, error) {
+	return randomHex(16)
+}
+
+// HashRefreshToken get the SHA-256 hash of the refresh token stored in the database
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
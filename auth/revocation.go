@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"time"
+)
+
+// RevocationStore keep the tokens revoked before their expiry
+type RevocationStore interface {
+	// Revoked check the token or its login session was revoked, or it was issued before the current token version of its user
+	Revoked(tokenID, sessionID string, userID uint64, version int) (bool, error)
+	// Revoke revoke the token until its expiry
+	Revoke(tokenID string, userID uint64, expiresAt time.Time) error
+}
+
+// revocations store checked by ValidateToken, none until UseRevocationStore is called
+var revocations RevocationStore
+
+// UseRevocationStore set the store checked by ValidateToken
+func UseRevocationStore(store RevocationStore) {
+	revocations = store
+}
+
+// Revoke revoke the token until its expiry, the invalid tokens are ignored
+func Revoke(tokenString string) error {
+	token, tokenErr := ValidateToken(tokenString)
+	if tokenErr != nil || revocations == nil {
+		return nil
+	}
+	claims := tokenClaims(token)
+	expiresAt, expiresErr := token.Claims.GetExpirationTime()
+	if expiresErr != nil || expiresAt == nil {
+		return expiresErr
+	}
+	return revocations.Revoke(claims.tokenID, claims.userID, expiresAt.Time)
+}
@@ -0,0 +1,80 @@
+package cursor
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+)
+
+const InvalidCursorError string = "invalid cursor"
+const SortMismatchError string = "the cursor was created for another sort"
+
+// payload content of the cursor token
+type payload struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+}
+
+// key get the key signing the cursors, SECRET_CURSOR
+// Without it the key is derived from SECRET_JWT, so the JWT secret never signs anything else than the tokens
+func key() []byte {
+	if secret := os.Getenv("SECRET_CURSOR"); secret != "" {
+		return []byte(secret)
+	}
+	mac := hmac.New(sha256.New, []byte(os.Getenv("SECRET_JWT")))
+	mac.Write([]byte("cursor"))
+	return mac.Sum(nil)
+}
+
+// sign compute the signature of the encoded payload
+func sign(encoded string) string {
+	mac := hmac.New(sha256.New, key())
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Encode create the opaque token of a position in the sort order
+// @param sort signature of the sort the values belong to
+// @param values values of the sort keys of the row
+func Encode(sort string, values []any) string {
+	data, _ := json.Marshal(payload{Sort: sort, Values: values})
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + sign(encoded)
+}
+
+// Decode check the signature of the token and read its values
+// The token must have been created for the same sort
+func Decode(token string, sort string) ([]any, error) {
+	encoded, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(sign(encoded))) {
+		return nil, errors.New(InvalidCursorError)
+	}
+	data, decodeErr := base64.RawURLEncoding.DecodeString(encoded)
+	if decodeErr != nil {
+		return nil, errors.New(InvalidCursorError)
+	}
+	var content payload
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if jsonErr := decoder.Decode(&content); jsonErr != nil {
+		return nil, errors.New(InvalidCursorError)
+	}
+	if content.Sort != sort {
+		return nil, errors.New(SortMismatchError)
+	}
+	for i, value := range content.Values {
+		if number, isNumber := value.(json.Number); isNumber {
+			if integer, intErr := number.Int64(); intErr == nil {
+				content.Values[i] = integer
+			} else {
+				content.Values[i] = number.String()
+			}
+		}
+	}
+	return content.Values, nil
+}
@@ -0,0 +1,81 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	t.Setenv("SECRET_CURSOR", "cursor-secret")
+	tests := []struct {
+		name   string
+		values []any
+		want   []any
+	}{
+		{name: "integer", values: []any{int64(42)}, want: []any{int64(42)}},
+		{name: "string and integer", values: []any{"2026-10-18 09:00:00", uint64(7)}, want: []any{"2026-10-18 09:00:00", int64(7)}},
+		{name: "null", values: []any{nil, int64(3)}, want: []any{nil, int64(3)}},
+		{name: "decimal", values: []any{1.5}, want: []any{"1.5"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token := Encode("due_at,asc", test.values)
+			got, err := Decode(token, "due_at,asc")
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Decode = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRejects(t *testing.T) {
+	t.Setenv("SECRET_CURSOR", "cursor-secret")
+	token := Encode("due_at,asc", []any{"2026-10-18 09:00:00", int64(7)})
+	encoded, signature, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"s":"due_at,asc","v":["2026-10-18 09:00:00",1]}`))
+	tests := []struct {
+		name  string
+		token string
+		sort  string
+		want  string
+	}{
+		{name: "tampered payload", token: forged + "." + signature, sort: "due_at,asc", want: InvalidCursorError},
+		{name: "tampered signature", token: encoded + "." + strings.Repeat("A", len(signature)), sort: "due_at,asc", want: InvalidCursorError},
+		{name: "missing signature", token: encoded, sort: "due_at,asc", want: InvalidCursorError},
+		{name: "garbage", token: "not-a-cursor", sort: "due_at,asc", want: InvalidCursorError},
+		{name: "another sort", token: token, sort: "priority,desc", want: SortMismatchError},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Decode(test.token, test.sort); err == nil || err.Error() != test.want {
+				t.Fatalf("Decode error %v, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsAnotherKey(t *testing.T) {
+	t.Setenv("SECRET_CURSOR", "cursor-secret")
+	token := Encode("due_at,asc", []any{int64(7)})
+	t.Setenv("SECRET_CURSOR", "another-secret")
+	if _, err := Decode(token, "due_at,asc"); err == nil || err.Error() != InvalidCursorError {
+		t.Fatalf("Decode error %v, want %q", err, InvalidCursorError)
+	}
+}
+
+func TestKeyIsNotTheJWTSecret(t *testing.T) {
+	t.Setenv("SECRET_CURSOR", "")
+	t.Setenv("SECRET_JWT", "jwt-secret")
+	if string(key()) == "jwt-secret" {
+		t.Fatal("the cursors are signed with the JWT secret")
+	}
+	token := Encode("due_at,asc", []any{int64(7)})
+	if _, err := Decode(token, "due_at,asc"); err != nil {
+		t.Fatalf("Decode with the derived key: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const ArchiveItemError string = "can not archive item, %s"
+const NotCompletedError string = "only the completed items can be archived"
+
+// Archived get the archived items, the most recently archived first
+// Accept the filters and the pagination of List
+func (items Items) Archived(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	filter, filterErr := ParseItemFilter(c, userID, userLocation(items.Users, userID))
+	if filterErr != nil {
+		c.AbortWithError(http.StatusBadRequest, filterErr)
+		return
+	}
+	filter.Archived = true
+	if len(filter.Sort) == 0 {
+		filter.Sort = []repository.SortKey{{Column: "archived_at", Desc: true}}
+	}
+	items.writeItemPage(c, userID, filter)
+}
+
+// Archive move the completed item out of the main list
+func (items Items) Archive(c *gin.Context) {
+	items.setArchived(c, true)
+}
+
+// Unarchive bring the archived item back to the main list
+func (items Items) Unarchive(c *gin.Context) {
+	items.setArchived(c, false)
+}
+
+// setArchived archive the item of the param, or take it out of the archive
+func (items Items) setArchived(c *gin.Context, archived bool) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	items = items.asActor(c, userID)
+	item, findItemErr := items.Repository.Find(itemId, userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findItemErr.Error()))
+		return
+	}
+	if code, authorizeErr := authorizeItem(items.Shares, item, userID, model.RoleEditor); authorizeErr != nil {
+		c.AbortWithError(code, authorizeErr)
+		return
+	}
+	if archived && item.Status != model.StatusCompleted {
+		c.AbortWithError(http.StatusBadRequest, errors.New(NotCompletedError))
+		return
+	}
+	if archivedErr := items.Repository.Archive(item.ItemId, archived); archivedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(ArchiveItemError, archivedErr.Error()))
+		return
+	}
+	updated, findUpdatedErr := items.Repository.Find(itemId, userID)
+	if findUpdatedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findUpdatedErr.Error()))
+		return
+	}
+	WriteResultWithItem(http.StatusOK, updated, c)
+}
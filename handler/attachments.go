@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/daniel-vuky/golang-todo-list-v2/storage"
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// MaxAttachmentSize biggest file accepted by the upload, in bytes
+const MaxAttachmentSize int64 = 10 << 20
+
+// multipartOverhead room left for the multipart boundaries and headers around the file
+const multipartOverhead int64 = 1 << 20
+
+// maxFilenameLength length of the filename column
+const maxFilenameLength int = 255
+
+const AttachmentFormField string = "file"
+const CreateAttachmentError string = "can not upload the attachment, %s"
+const DeleteAttachmentError string = "can not delete the attachment, %s"
+const FindAllAttachmentError string = "can not get the attachments, %s"
+const FindAttachmentError string = "can not find the attachment with ID, %d, %s"
+const AttachmentMissingError string = "please upload the file in the file field"
+const AttachmentSizeError string = "the file must be at most %d bytes"
+const AttachmentTypeError string = "files of type %s can not be attached"
+const AttachmentDeleteForbiddenError string = "only the uploader or an owner of the item can delete the attachment"
+
+// AllowedAttachmentTypes MIME types accepted by the upload, detected from the content of the file
+var AllowedAttachmentTypes = []string{
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"application/pdf",
+	"text/plain",
+	"text/csv",
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation",
+	"application/vnd.oasis.opendocument.text",
+	"application/vnd.oasis.opendocument.spreadsheet",
+}
+
+type Attachments struct {
+	Repository *repository.AttachmentsRepository
+	Items      *repository.ItemsRepository
+	Blobs      storage.BlobStore
+	Shares     *repository.SharesRepository
+}
+
+// findItemFromParam load the item of the :id param for the logged in user
+// Abort the request when the item can not be found
+func (attachments Attachments) findItemFromParam(c *gin.Context) (model.Item, uint64, bool) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return model.Item{}, 0, false
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return model.Item{}, 0, false
+	}
+	item, findItemErr := attachments.Items.Find(itemId, userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindItemError, itemId, findItemErr))
+		return model.Item{}, 0, false
+	}
+	return item, userID, true
+}
+
+// findAttachmentFromParam load the attachment of the :id param with its item for the logged in user
+// Abort the request when the attachment can not be found
+func (attachments Attachments) findAttachmentFromParam(c *gin.Context) (model.Attachment, model.Item, uint64, bool) {
+	attachmentId, attachmentIdErr := strconv.ParseUint(c.Param("id"), 10, 64)
+	if attachmentIdErr != nil || attachmentId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return model.Attachment{}, model.Item{}, 0, false
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return model.Attachment{}, model.Item{}, 0, false
+	}
+	attachment, findAttachmentErr := attachments.Repository.Find(attachmentId)
+	if findAttachmentErr != nil || attachment.AttachmentId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindAttachmentError, attachmentId, findAttachmentErr))
+		return model.Attachment{}, model.Item{}, 0, false
+	}
+	item, findItemErr := attachments.Items.Find(int(attachment.ItemId), userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindAttachmentError, attachmentId, findItemErr))
+		return model.Attachment{}, model.Item{}, 0, false
+	}
+	return attachment, item, userID, true
+}
+
+// isAllowedAttachmentType check the detected MIME type against AllowedAttachmentTypes
+func isAllowedAttachmentType(detected *mimetype.MIME) bool {
+	for _, allowed := range AllowedAttachmentTypes {
+		if detected.Is(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// attachmentFilename keep the base name of the uploaded file, without the path sent by some browsers
+func attachmentFilename(filename string) string {
+	filename = path.Base(strings.ReplaceAll(filename, "\\", "/"))
+	if filename == "." || filename == "/" {
+		filename = "attachment"
+	}
+	if len(filename) > maxFilenameLength {
+		filename = filename[len(filename)-maxFilenameLength:]
+	}
+	return filename
+}
+
+// newStorageKey get a random key for a blob of the item
+func newStorageKey(itemID uint64) (string, error) {
+	random := make([]byte, 16)
+	if _, randErr := rand.Read(random); randErr != nil {
+		return "", randErr
+	}
+	return fmt.Sprintf("items/%d/%s", itemID, hex.EncodeToString(random)), nil
+}
+
+// List get the attachments of the item, the oldest first
+func (attachments Attachments) List(c *gin.Context) {
+	item, _, found := attachments.findItemFromParam(c)
+	if !found {
+		return
+	}
+	listAttachments, findAllErr := attachments.Repository.FindAll(item.ItemId)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllAttachmentError, findAllErr.Error()))
+		return
+	}
+	WriteResultWithAttachments(http.StatusOK, listAttachments, c)
+}
+
+// Create upload a file to the item, multipart form with the file in the file field
+// The type is detected from the content, the type sent by the client is ignored
+func (attachments Attachments) Create(c *gin.Context) {
+	item, userID, found := attachments.findItemFromParam(c)
+	if !found {
+		return
+	}
+	if code, authorizeErr := authorizeItem(attachments.Shares, item, userID, model.RoleEditor); authorizeErr != nil {
+		c.AbortWithError(code, authorizeErr)
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxAttachmentSize+multipartOverhead)
+	fileHeader, formErr := c.FormFile(AttachmentFormField)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(formErr, &maxBytesErr) {
+		c.AbortWithError(http.StatusRequestEntityTooLarge, fmt.Errorf(AttachmentSizeError, MaxAttachmentSize))
+		return
+	}
+	if formErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(AttachmentMissingError))
+		return
+	}
+	if fileHeader.Size > MaxAttachmentSize {
+		c.AbortWithError(http.StatusRequestEntityTooLarge, fmt.Errorf(AttachmentSizeError, MaxAttachmentSize))
+		return
+	}
+	file, openErr := fileHeader.Open()
+	if openErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(AttachmentMissingError))
+		return
+	}
+	defer file.Close()
+
+	detected, detectErr := mimetype.DetectReader(file)
+	if detectErr != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(CreateAttachmentError, detectErr.Error()))
+		return
+	}
+	if !isAllowedAttachmentType(detected) {
+		c.AbortWithError(http.StatusUnsupportedMediaType, fmt.Errorf(AttachmentTypeError, detected.String()))
+		return
+	}
+	if _, seekErr := file.Seek(0, io.SeekStart); seekErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateAttachmentError, seekErr.Error()))
+		return
+	}
+
+	storageKey, keyErr := newStorageKey(item.ItemId)
+	if keyErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateAttachmentError, keyErr.Error()))
+		return
+	}
+	newAttachment := model.Attachment{
+		ItemId:      item.ItemId,
+		UserId:      userID,
+		Filename:    attachmentFilename(fileHeader.Filename),
+		ContentType: detected.String(),
+		Size:        fileHeader.Size,
+		StorageKey:  storageKey,
+	}
+	ctx := c.Request.Context()
+	if putErr := attachments.Blobs.Put(ctx, storageKey, file, fileHeader.Size, newAttachment.ContentType); putErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateAttachmentError, putErr.Error()))
+		return
+	}
+	if insertErr := attachments.Repository.Insert(&newAttachment); insertErr != nil {
+		attachments.Blobs.Delete(ctx, storageKey)
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateAttachmentError, insertErr.Error()))
+		return
+	}
+	saved, findErr := attachments.Repository.Find(newAttachment.AttachmentId)
+	if findErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAttachmentError, newAttachment.AttachmentId, findErr.Error()))
+		return
+	}
+	WriteResultWithAttachment(http.StatusOK, saved, c)
+}
+
+// Download send the content of the attachment as a file download
+func (attachments Attachments) Download(c *gin.Context) {
+	attachment, _, _, found := attachments.findAttachmentFromParam(c)
+	if !found {
+		return
+	}
+	blob, getErr := attachments.Blobs.Get(c.Request.Context(), attachment.StorageKey)
+	if errors.Is(getErr, storage.ErrBlobNotFound) {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindAttachmentError, attachment.AttachmentId, getErr.Error()))
+		return
+	}
+	if getErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAttachmentError, attachment.AttachmentId, getErr.Error()))
+		return
+	}
+	defer blob.Close()
+
+	c.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, blob, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// DeleteByID delete the attachment, only by its uploader or a user with the owner role on the item
+func (attachments Attachments) DeleteByID(c *gin.Context) {
+	attachment, item, userID, found := attachments.findAttachmentFromParam(c)
+	if !found {
+		return
+	}
+	if attachment.UserId != userID {
+		if _, authorizeErr := authorizeItem(attachments.Shares, item, userID, model.RoleOwner); authorizeErr != nil {
+			c.AbortWithError(http.StatusForbidden, errors.New(AttachmentDeleteForbiddenError))
+			return
+		}
+	}
+	if deletedErr := attachments.Repository.Delete(attachment.AttachmentId); deletedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(DeleteAttachmentError, deletedErr.Error()))
+		return
+	}
+	purgeAttachments(c.Request.Context(), attachments.Repository, attachments.Blobs, []uint64{attachment.AttachmentId})
+	WriteResult(http.StatusOK, "Deleted record", c)
+}
+
+// purgeAttachments remove the blobs of the attachments detached by a committed delete
+// A failure is only logged, the background job purges the blobs left behind
+func purgeAttachments(ctx context.Context, attachmentsRepository *repository.AttachmentsRepository, blobs storage.BlobStore, attachmentIDs []uint64) {
+	if len(attachmentIDs) == 0 {
+		return
+	}
+	purged, purgeErr := attachmentsRepository.PurgeDetached(ctx, blobs, attachmentIDs...)
+	if purgeErr != nil {
+		fmt.Printf("Fail to purge the attachments, %s\n", purgeErr.Error())
+	} else if purged > 0 {
+		fmt.Printf("Purged %d attachments\n", purged)
+	}
+}
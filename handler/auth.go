@@ -1,46 +1,124 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/auth"
 	"github.com/daniel-vuky/golang-todo-list-v2/model"
 	"github.com/daniel-vuky/golang-todo-list-v2/repository"
 	"github.com/gin-gonic/gin"
 	ginSession "github.com/go-session/gin-session"
 	"net/http"
 	"regexp"
+	"strings"
+	"time"
 )
 
 const EmailRegex string = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
+const DefaultTimezone string = "UTC"
+const InvalidTimezoneError string = "invalid timezone, %s"
+const UpdateTimezoneError string = "can not update timezone, %s"
+const UnauthorizedError string = "you need to login first"
+const InvalidTokenError string = "the token is invalid or expired"
+const CreateTokenError string = "can not create the token, %s"
+const LogoutAllError string = "can not log out everywhere, %s"
+
+// UserIDContextKey key of the ID of the user authenticated by a bearer token in the gin context
+const UserIDContextKey string = "auth_user_id"
 
 type Users struct {
-	Repository *repository.UsersRepository
-	Auth       *repository.AuthRepository
+	Repository     *repository.UsersRepository
+	Auth           *repository.AuthRepository
+	RefreshTokens  *repository.RefreshTokensRepository
+	Sessions       *repository.LoginSessionsRepository
+	PersonalTokens *repository.PersonalAccessTokensRepository
 }
 
+// AuthMiddleware authenticate the request with the access token of the Authorization: Bearer header,
+// or with the token of the session of the browser
+// The login session of the token is kept in the context, see touchSession
+// The personal access tokens are refused, see AuthMiddlewareWithScope
 func (users Users) AuthMiddleware(c *gin.Context) {
+	users.authenticate("", c)
+}
+
+// AuthMiddlewareWithScope authenticate the request like AuthMiddleware,
+// the personal access tokens granted the scope are accepted too
+func (users Users) AuthMiddlewareWithScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		users.authenticate(scope, c)
+	}
+}
+
+// authenticate authenticate the request with a bearer token or the session of the browser
+// The personal access tokens need the scope, they are refused when it is empty
+func (users Users) authenticate(scope string, c *gin.Context) {
+	if header := c.GetHeader("Authorization"); header != "" {
+		scheme, tokenString, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			Unauthorized(InvalidTokenError, c)
+			return
+		}
+		tokenString = strings.TrimSpace(tokenString)
+		if strings.HasPrefix(tokenString, auth.PersonalAccessTokenPrefix) {
+			users.authenticatePersonalToken(tokenString, scope, c)
+			return
+		}
+		claims, tokenErr := users.Auth.ParseAccessToken(tokenString)
+		if tokenErr != nil {
+			Unauthorized(InvalidTokenError, c)
+			return
+		}
+		c.Set(UserIDContextKey, claims.UserID)
+		users.touchSession(claims.SessionID, c)
+		c.Next()
+		return
+	}
+
 	session := ginSession.FromContext(c)
 	if session == nil {
-		c.Redirect(http.StatusFound, "/login")
-		c.Abort()
+		Unauthorized(UnauthorizedError, c)
 		return
 	}
 	tokenString, tokenFine := session.Get("token")
 
 	if tokenString == nil || !tokenFine {
-		// Redirect to login page if not authenticated
-		c.Redirect(http.StatusFound, "/login")
-		c.Abort()
+		Unauthorized(UnauthorizedError, c)
 		return
 	}
 
-	token, err := users.Auth.ParseToken(tokenString.(string))
+	claims, err := users.Auth.ParseAccessToken(tokenString.(string))
+
+	// The expired access token of the session is renewed with its refresh token
+	if err != nil {
+		if !users.refreshSession(session) {
+			Unauthorized(InvalidTokenError, c)
+			return
+		}
+		tokenString, _ = session.Get("token")
+		claims, err = users.Auth.ParseAccessToken(tokenString.(string))
+		if err != nil {
+			Unauthorized(InvalidTokenError, c)
+			return
+		}
+	}
+	users.touchSession(claims.SessionID, c)
+
+	c.Next()
+}
 
-	if err != nil || !token.Valid {
+// Unauthorized abort the request of an unauthenticated user
+// The pages opened by the browsers are redirected to the login page, the API clients get a JSON 401
+func Unauthorized(message string, c *gin.Context) {
+	if c.GetHeader("Authorization") == "" && strings.Contains(c.GetHeader("Accept"), "text/html") {
 		c.Redirect(http.StatusFound, "/login")
 		c.Abort()
 		return
 	}
-
-	c.Next()
+	c.Header("WWW-Authenticate", `Bearer realm="api"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+		"message": message,
+	})
 }
 
 // GetUsernameFromContext retrieves the username from the JWT token
@@ -60,6 +138,62 @@ func (users Users) GetUsernameFromContext(c *gin.Context) string {
 	return username
 }
 
+// GetUserIDFromSession retrieves the logged in user ID from the bearer token or the session
+func GetUserIDFromSession(c *gin.Context) (uint64, bool) {
+	if userID, authenticated := c.Get(UserIDContextKey); authenticated {
+		id, ok := userID.(uint64)
+		return id, ok
+	}
+	session := ginSession.FromContext(c)
+	if session == nil {
+		return 0, false
+	}
+	userID, userIDExisted := session.Get("user_id")
+	if !userIDExisted {
+		return 0, false
+	}
+	id, ok := userID.(uint64)
+	return id, ok
+}
+
+// GetTimezoneFromContext retrieves the timezone of the logged in user
+func (users Users) GetTimezoneFromContext(c *gin.Context) string {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		return DefaultTimezone
+	}
+	timezone, timezoneErr := users.Repository.GetTimezone(userID)
+	if timezoneErr != nil || timezone == "" {
+		return DefaultTimezone
+	}
+	return timezone
+}
+
+// UpdateTimezone change the timezone of the logged in user
+func (users Users) UpdateTimezone(c *gin.Context) {
+	var input struct {
+		Timezone string `json:"timezone"`
+	}
+	if bindErr := c.ShouldBindJSON(&input); bindErr != nil || input.Timezone == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	if _, locErr := time.LoadLocation(input.Timezone); locErr != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(InvalidTimezoneError, locErr.Error()))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	if updatedErr := users.Repository.UpdateTimezone(userID, input.Timezone); updatedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(UpdateTimezoneError, updatedErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Updated", c)
+}
+
 // Register user
 func (users Users) Register(c *gin.Context) {
 	username := c.PostForm("username")
@@ -111,13 +245,14 @@ func (users Users) Login(c *gin.Context) {
 		Redirect("login", repository.UsernamePasswordErrorCode, c)
 		return
 	}
-	token, tokenErr := users.Auth.CreateToken(user.Username)
-	if tokenErr != nil {
+	pair, pairErr := users.startSession(user, c)
+	if pairErr != nil {
 		Redirect("login", repository.ErrorEncounteredErrorCode, c)
 		return
 	}
 	session := ginSession.FromContext(c)
-	session.Set("token", token)
+	session.Set("token", pair.AccessToken)
+	session.Set("refresh_token", pair.RefreshToken)
 	session.Set("user_id", user.UserId)
 	sessionErr := session.Save()
 	if sessionErr != nil {
@@ -130,11 +265,72 @@ func (users Users) Login(c *gin.Context) {
 }
 
 // Logout Post Login
+// The access token and the refresh tokens of the session are revoked
 func (users Users) Logout(c *gin.Context) {
 	session := ginSession.FromContext(c)
+	if tokenString, existed := session.Get("token"); existed {
+		users.Auth.RevokeToken(tokenString.(string))
+	}
+	if refreshToken, existed := session.Get("refresh_token"); existed {
+		users.revokeRefreshToken(refreshToken.(string))
+	}
 	session.Delete("token")
+	session.Delete("refresh_token")
 	session.Save()
 	c.Redirect(http.StatusFound, "/login")
 	c.Abort()
 	return
 }
+
+// LogoutAll revoke all the access tokens and refresh tokens of the user, on every device
+func (users Users) LogoutAll(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	// Revoke the refresh tokens first, so no access token of the new version is issued from them
+	if revokeErr := users.RefreshTokens.RevokeUser(userID); revokeErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(LogoutAllError, revokeErr.Error()))
+		return
+	}
+	if versionErr := users.Repository.IncrementTokenVersion(userID); versionErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(LogoutAllError, versionErr.Error()))
+		return
+	}
+	if session := ginSession.FromContext(c); session != nil {
+		session.Delete("token")
+		session.Delete("refresh_token")
+		session.Save()
+	}
+	WriteResult(http.StatusOK, "Logged out everywhere", c)
+}
+
+// Token exchange the username and password for a token pair, for the API clients
+// Send the access token in the Authorization: Bearer header
+func (users Users) Token(c *gin.Context) {
+	var credentials model.Credentials
+	bindErr := c.ShouldBind(&credentials)
+	if bindErr != nil || credentials.Username == "" || credentials.Password == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	user := model.User{
+		Username: credentials.Username,
+	}
+	getUserErr := users.Repository.GetUser(&user)
+	if getUserErr != nil || user.UserId == 0 {
+		Unauthorized(repository.UsernamePasswordError, c)
+		return
+	}
+	if hashedError := users.Auth.ComparePasswordHash(user.Password, credentials.Password); hashedError != nil {
+		Unauthorized(repository.UsernamePasswordError, c)
+		return
+	}
+	pair, pairErr := users.startSession(user, c)
+	if pairErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateTokenError, pairErr.Error()))
+		return
+	}
+	WriteResultWithTokenPair(http.StatusOK, pair, c)
+}
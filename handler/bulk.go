@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+const MaxBulkOperations int = 100
+const BulkSizeError string = "send between 1 and %d operations"
+const BulkOperationError string = "unknown operation %q, use create, update or delete"
+const BulkTransactionError string = "can not run the bulk operations, %s"
+
+// Bulk run a batch of create, update and delete operations in a single transaction
+// Each operation is isolated by a savepoint, so a failure only cancels its own changes,
+// unless atomic is set, then the first failure rolls back the whole batch
+func (items Items) Bulk(c *gin.Context) {
+	var request model.BulkRequest
+	if bindErr := c.ShouldBindJSON(&request); bindErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	if len(request.Operations) == 0 || len(request.Operations) > MaxBulkOperations {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(BulkSizeError, MaxBulkOperations))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	items = items.asActor(c, userID)
+	txRepository, txErr := items.Repository.Begin()
+	if txErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(BulkTransactionError, txErr.Error()))
+		return
+	}
+	defer txRepository.Tx.Rollback()
+	txItems := items
+	txItems.Repository = &txRepository
+
+	result := model.BulkResult{Results: make([]model.BulkOperationResult, len(request.Operations))}
+	failed := false
+	// The attachments of the items deleted for good, purged once the batch is committed
+	// Those of the operations rolled back to their savepoint are still attached and skipped by the purge
+	detached := []uint64{}
+	for index, operation := range request.Operations {
+		result.Results[index] = model.BulkOperationResult{Index: index, Op: operation.Op, ItemId: operation.Id}
+		if failed {
+			result.Results[index].Status = model.BulkStatusSkipped
+			continue
+		}
+		savepoint := fmt.Sprintf("bulk_%d", index)
+		if !request.Atomic {
+			if savepointErr := txRepository.Savepoint(savepoint); savepointErr != nil {
+				c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(BulkTransactionError, savepointErr.Error()))
+				return
+			}
+		}
+		item, operationDetached, operationErr := txItems.runBulkOperation(operation, userID)
+		if operationErr == nil {
+			detached = append(detached, operationDetached...)
+			result.Results[index].Status = model.BulkStatusOK
+			if item != nil {
+				result.Results[index].ItemId = item.ItemId
+				result.Results[index].Item = item
+			}
+			continue
+		}
+		result.Results[index].Status = model.BulkStatusFailed
+		result.Results[index].Error = operationErr.Error()
+		if request.Atomic {
+			failed = true
+			continue
+		}
+		if rollbackErr := txRepository.RollbackToSavepoint(savepoint); rollbackErr != nil {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(BulkTransactionError, rollbackErr.Error()))
+			return
+		}
+	}
+
+	if failed {
+		for index := range result.Results {
+			if result.Results[index].Status == model.BulkStatusOK {
+				result.Results[index].Status = model.BulkStatusRolledBack
+				result.Results[index].Item = nil
+			}
+		}
+		WriteResultWithBulkResult(http.StatusUnprocessableEntity, result, c)
+		return
+	}
+	if commitErr := txRepository.Tx.Commit(); commitErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(BulkTransactionError, commitErr.Error()))
+		return
+	}
+	result.Committed = true
+	purgeAttachments(c.Request.Context(), items.Attachments, items.Blobs, detached)
+	WriteResultWithBulkResult(http.StatusOK, result, c)
+}
+
+// runBulkOperation run one operation of the batch
+// Return the created or updated item, nil for a delete
+// Return the attachments detached by a delete for good, see deleteItem
+func (items Items) runBulkOperation(operation model.BulkOperation, userID uint64) (*model.Item, []uint64, error) {
+	switch operation.Op {
+	case model.BulkCreate:
+		if operation.Item == nil {
+			return nil, nil, errors.New(BindInputError)
+		}
+		created, _, createErr := items.createItem(*operation.Item, userID)
+		if createErr != nil {
+			return nil, nil, createErr
+		}
+		return &created, nil, nil
+	case model.BulkUpdate:
+		if operation.Id == 0 {
+			return nil, nil, errors.New(MissingInputID)
+		}
+		if len(operation.Patch) == 0 {
+			return nil, nil, errors.New(BindInputError)
+		}
+		updated, _, patchErr := items.patchItem(int(operation.Id), userID, operation.Patch)
+		if patchErr != nil {
+			return nil, nil, patchErr
+		}
+		return &updated, nil, nil
+	case model.BulkDelete:
+		if operation.Id == 0 {
+			return nil, nil, errors.New(MissingInputID)
+		}
+		childrenMode := operation.Children
+		if childrenMode == "" {
+			childrenMode = ChildrenCascade
+		}
+		detached, _, deleteErr := items.deleteItem(int(operation.Id), userID, childrenMode, operation.Permanent)
+		return nil, detached, deleteErr
+	}
+	return nil, nil, fmt.Errorf(BulkOperationError, operation.Op)
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/markdown"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const CreateCommentError string = "can not create comment, %s"
+const UpdateCommentError string = "can not update comment, %s"
+const DeleteCommentError string = "can not delete comment, %s"
+const FindAllCommentError string = "can not get the comments, %s"
+const FindCommentError string = "can not find the comment with ID, %d, %s"
+const CommentBodyError string = "the comment must have between 1 and %d characters"
+const CommentAuthorError string = "only the author can edit the comment"
+const CommentDeleteForbiddenError string = "only the author or an owner of the item can delete the comment"
+
+type Comments struct {
+	Repository *repository.CommentsRepository
+	Items      *repository.ItemsRepository
+	Shares     *repository.SharesRepository
+}
+
+// findItemFromParam load the item of the :id param for the logged in user
+// Abort the request when the item can not be found
+func (comments Comments) findItemFromParam(c *gin.Context) (model.Item, uint64, bool) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return model.Item{}, 0, false
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return model.Item{}, 0, false
+	}
+	item, findItemErr := comments.Items.Find(itemId, userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindItemError, itemId, findItemErr))
+		return model.Item{}, 0, false
+	}
+	return item, userID, true
+}
+
+// findCommentFromParam load the comment of the :comment_id param on the item
+// Abort the request when the comment can not be found
+func (comments Comments) findCommentFromParam(c *gin.Context, item model.Item) (model.Comment, bool) {
+	commentId, commentIdErr := strconv.ParseUint(c.Param("comment_id"), 10, 64)
+	if commentIdErr != nil || commentId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return model.Comment{}, false
+	}
+	comment, findCommentErr := comments.Repository.Find(commentId, item.ItemId)
+	if findCommentErr != nil || comment.CommentId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindCommentError, commentId, findCommentErr))
+		return model.Comment{}, false
+	}
+	return comment, true
+}
+
+// bindCommentInput read and check the Markdown body of the comment
+func bindCommentInput(c *gin.Context) (model.CommentInput, bool) {
+	var commentInput model.CommentInput
+	if bindErr := c.ShouldBindJSON(&commentInput); bindErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return commentInput, false
+	}
+	commentInput.Body = strings.TrimSpace(commentInput.Body)
+	if len(commentInput.Body) == 0 || len(commentInput.Body) > markdown.MaxLength {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(CommentBodyError, markdown.MaxLength))
+		return commentInput, false
+	}
+	return commentInput, true
+}
+
+// renderComments fill the sanitized HTML of the comments
+func renderComments(listComments []model.Comment) error {
+	for i := range listComments {
+		html, renderErr := markdown.Render(listComments[i].Body)
+		if renderErr != nil {
+			return renderErr
+		}
+		listComments[i].Html = html
+	}
+	return nil
+}
+
+// List get the comments of the item, the oldest first
+// Paginate with ?size=N&p=N
+func (comments Comments) List(c *gin.Context) {
+	item, _, found := comments.findItemFromParam(c)
+	if !found {
+		return
+	}
+	pageSize, currentPage := GetPagination(c)
+	listComments, findAllErr := comments.Repository.FindAll(item.ItemId, pageSize, (currentPage-1)*pageSize)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllCommentError, findAllErr.Error()))
+		return
+	}
+	if renderErr := renderComments(listComments); renderErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllCommentError, renderErr.Error()))
+		return
+	}
+	WriteResultWithComments(http.StatusOK, listComments, c)
+}
+
+// Create add a comment to the item
+func (comments Comments) Create(c *gin.Context) {
+	item, userID, found := comments.findItemFromParam(c)
+	if !found {
+		return
+	}
+	commentInput, valid := bindCommentInput(c)
+	if !valid {
+		return
+	}
+	newComment := model.Comment{
+		ItemId: item.ItemId,
+		UserId: userID,
+		Body:   commentInput.Body,
+	}
+	if insertErr := comments.Repository.Insert(&newComment); insertErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateCommentError, insertErr.Error()))
+		return
+	}
+	comments.writeComment(c, http.StatusOK, newComment)
+}
+
+// UpdateByID change the body of the comment, only by its author
+func (comments Comments) UpdateByID(c *gin.Context) {
+	item, userID, found := comments.findItemFromParam(c)
+	if !found {
+		return
+	}
+	comment, commentFound := comments.findCommentFromParam(c, item)
+	if !commentFound {
+		return
+	}
+	if comment.UserId != userID {
+		c.AbortWithError(http.StatusForbidden, errors.New(CommentAuthorError))
+		return
+	}
+	commentInput, valid := bindCommentInput(c)
+	if !valid {
+		return
+	}
+	if updatedErr := comments.Repository.Update(&comment, &commentInput); updatedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(UpdateCommentError, updatedErr.Error()))
+		return
+	}
+	comments.writeComment(c, http.StatusOK, comment)
+}
+
+// DeleteByID delete the comment, only by its author or a user with the owner role on the item
+func (comments Comments) DeleteByID(c *gin.Context) {
+	item, userID, found := comments.findItemFromParam(c)
+	if !found {
+		return
+	}
+	comment, commentFound := comments.findCommentFromParam(c, item)
+	if !commentFound {
+		return
+	}
+	if comment.UserId != userID {
+		if _, authorizeErr := authorizeItem(comments.Shares, item, userID, model.RoleOwner); authorizeErr != nil {
+			c.AbortWithError(http.StatusForbidden, errors.New(CommentDeleteForbiddenError))
+			return
+		}
+	}
+	if deletedErr := comments.Repository.Delete(comment.CommentId); deletedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(DeleteCommentError, deletedErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Deleted record", c)
+}
+
+// writeComment reload the comment and write it with its rendered HTML
+func (comments Comments) writeComment(c *gin.Context, code int, comment model.Comment) {
+	saved, findErr := comments.Repository.Find(comment.CommentId, comment.ItemId)
+	if findErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindCommentError, comment.CommentId, findErr.Error()))
+		return
+	}
+	rendered := []model.Comment{saved}
+	if renderErr := renderComments(rendered); renderErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindCommentError, comment.CommentId, renderErr.Error()))
+		return
+	}
+	WriteResultWithComment(code, rendered[0], c)
+}
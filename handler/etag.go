@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const PreconditionFailedError string = "the item was changed since it was read, reload it and try again"
+
+// ItemETag get the entity tag of the item, "version", or "version-hash" when the item has subtasks
+// or is expanded with its subtasks tree
+// The hash covers the versions of the subtasks, so their changes refresh the progress cached by the clients,
+// and the representation, so the expanded and the plain item never share a tag
+func ItemETag(item model.Item, descendants []model.Item, expanded bool) string {
+	if len(descendants) == 0 && !expanded {
+		return fmt.Sprintf(`"%d"`, item.Version)
+	}
+	hash := sha256.New()
+	if expanded {
+		fmt.Fprint(hash, ExpandChildren+";")
+	}
+	for _, descendant := range descendants {
+		fmt.Fprintf(hash, "%d:%d;", descendant.ItemId, descendant.Version)
+	}
+	return fmt.Sprintf(`"%d-%x"`, item.Version, hash.Sum(nil)[:8])
+}
+
+// pageETag get the weak entity tag of the page of items, a hash of its JSON representation
+func pageETag(page model.ItemPage) string {
+	encoded, _ := json.Marshal(page)
+	sum := sha256.Sum256(encoded)
+	return fmt.Sprintf(`W/"%x"`, sum[:8])
+}
+
+// etagVersion read the version of the item from its entity tag, weak tags are never matched
+func etagVersion(tag string) (int, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+	value, _, _ := strings.Cut(tag[1:len(tag)-1], "-")
+	version, versionErr := strconv.Atoi(value)
+	return version, versionErr == nil
+}
+
+// notModified write the entity tag and answer 304 Not Modified when the client has it, see If-None-Match
+// Return true when the response is done
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	ifNoneMatch := c.GetHeader("If-None-Match")
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		// If-None-Match uses the weak comparison
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// withIfMatch get the handler checking the If-Match header of the request before changing an item, see precondition
+func (items Items) withIfMatch(c *gin.Context) Items {
+	items.ifMatch = c.GetHeader("If-Match")
+	return items
+}
+
+// precondition check the If-Match header against the version of the item, no header means no precondition
+// Return the repository failing the change with ErrVersionConflict when the item is changed in the meantime,
+// or the HTTP status code of the error
+func (items Items) precondition(item model.Item) (*repository.ItemsRepository, int, error) {
+	if items.ifMatch == "" {
+		return items.Repository, http.StatusOK, nil
+	}
+	for _, tag := range strings.Split(items.ifMatch, ",") {
+		if strings.TrimSpace(tag) == "*" {
+			return items.Repository, http.StatusOK, nil
+		}
+		if version, valid := etagVersion(tag); valid && version == item.Version {
+			guarded := items.Repository.IfVersion(item.ItemId, item.Version)
+			return &guarded, http.StatusOK, nil
+		}
+	}
+	return nil, http.StatusPreconditionFailed, errors.New(PreconditionFailedError)
+}
+
+// writeErrorCode get the HTTP status code of an error returned by a change of the items
+func writeErrorCode(err error) int {
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return http.StatusPreconditionFailed
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const InvalidTagModeError string = "tag_mode must be any or all"
+const InvalidFilterError string = "invalid filter %s, %s"
+
+// MaxFilterIDs maximum number of IDs accepted by the ids filter
+const MaxFilterIDs int = 1000
+
+// ParseItemFilter read the filters of the item listing from the query:
+//
+//	list=ID                        items of the list
+//	assignee=me|ID                 items assigned to the logged in user or to the user with the ID
+//	tag=a&tag=b&tag_mode=any|all   items with any or all of the tags
+//	status=todo,done               items with one of the statuses, by name or number
+//	created_after, created_before  creation date range, in the timezone of the user
+//	updated_since                  items updated after the date
+//	has_description=true|false     items with or without description
+//	ids=1,2,3                      items with one of the IDs
+//	sort=priority,-due_at          whitelisted sort keys, "-" sorts descending
+func ParseItemFilter(c *gin.Context, userID uint64, loc *time.Location) (repository.ItemFilter, error) {
+	var filter repository.ItemFilter
+	if value := c.Query("list"); value != "" {
+		listID, listIDErr := strconv.ParseUint(value, 10, 64)
+		if listIDErr != nil || listID == 0 {
+			return filter, fmt.Errorf(InvalidFilterError, "list", "must be an ID")
+		}
+		filter.ListID = listID
+	}
+
+	if value := c.Query("assignee"); value == "me" {
+		filter.AssigneeID = userID
+	} else if value != "" {
+		assigneeID, assigneeIDErr := strconv.ParseUint(value, 10, 64)
+		if assigneeIDErr != nil || assigneeID == 0 {
+			return filter, fmt.Errorf(InvalidFilterError, "assignee", "must be me or an ID")
+		}
+		filter.AssigneeID = assigneeID
+	}
+
+	tags, tagsErr := model.NormalizeTags(c.QueryArray("tag"))
+	if tagsErr != nil {
+		return filter, tagsErr
+	}
+	filter.Tags = tags
+	filter.TagMode = c.DefaultQuery("tag_mode", repository.TagModeAny)
+	if filter.TagMode != repository.TagModeAny && filter.TagMode != repository.TagModeAll {
+		return filter, errors.New(InvalidTagModeError)
+	}
+
+	if value := c.Query("status"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			status, known := model.StatusNames[name]
+			if !known {
+				number, numberErr := strconv.Atoi(name)
+				if numberErr != nil || number <= 0 {
+					return filter, fmt.Errorf(InvalidFilterError, "status", fmt.Sprintf("unknown status %q", name))
+				}
+				status = number
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	for _, date := range []struct {
+		name  string
+		value *string
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_since", &filter.UpdatedSince},
+	} {
+		raw := c.Query(date.name)
+		parsed, parseErr := model.ParseInputTime(&raw, loc)
+		if parseErr != nil {
+			return filter, fmt.Errorf(InvalidFilterError, date.name, parseErr.Error())
+		}
+		if parsed != nil {
+			*date.value = parsed.UTC().Format(model.DatabaseTimeLayout)
+		}
+	}
+
+	if value := c.Query("has_description"); value != "" {
+		hasDescription, boolErr := strconv.ParseBool(value)
+		if boolErr != nil {
+			return filter, fmt.Errorf(InvalidFilterError, "has_description", "must be true or false")
+		}
+		filter.HasDescription = &hasDescription
+	}
+
+	if value := c.Query("ids"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			id, idErr := strconv.ParseUint(strings.TrimSpace(part), 10, 64)
+			if idErr != nil || id == 0 {
+				return filter, fmt.Errorf(InvalidFilterError, "ids", fmt.Sprintf("%q is not an ID", part))
+			}
+			filter.IDs = append(filter.IDs, id)
+		}
+		if len(filter.IDs) > MaxFilterIDs {
+			return filter, fmt.Errorf(InvalidFilterError, "ids", fmt.Sprintf("at most %d IDs", MaxFilterIDs))
+		}
+	}
+
+	sortKeys, sortErr := repository.ParseSort(c.Query("sort"))
+	if sortErr != nil {
+		return filter, sortErr
+	}
+	filter.Sort = sortKeys
+	return filter, nil
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const FindHistoryError string = "can not get the history of the item, %s"
+const InvalidVersionError string = "please enter a valid version"
+const RevertItemError string = "can not revert item, %s"
+
+// asActor get the handler recording the changes in the history as made by the user in this request
+func (items Items) asActor(c *gin.Context, userID uint64) Items {
+	itemsRepository := items.Repository.WithActor(model.Actor{
+		UserId:    userID,
+		RequestId: GetRequestIDFromContext(c),
+	})
+	items.Repository = &itemsRepository
+	return items
+}
+
+// History get the changes of the item, the latest first
+// Paginate with ?size=N&p=N
+func (items Items) History(c *gin.Context) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	pageSize, currentPage := GetPagination(c)
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	item, findItemErr := items.Repository.Find(itemId, userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindItemError, itemId, findItemErr))
+		return
+	}
+	entries, historyErr := items.Repository.History(item.ItemId, item.UserId, pageSize, (currentPage-1)*pageSize)
+	if historyErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindHistoryError, historyErr.Error()))
+		return
+	}
+	WriteResultWithHistory(http.StatusOK, entries, c)
+}
+
+// Revert restore the fields and the tags of the item as they were after the version
+// The revert is recorded as a new version
+func (items Items) Revert(c *gin.Context) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	version, versionErr := strconv.Atoi(c.Param("version"))
+	if versionErr != nil || version <= 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(InvalidVersionError))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	items = items.asActor(c, userID)
+	item, findItemErr := items.Repository.Find(itemId, userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findItemErr.Error()))
+		return
+	}
+	if code, authorizeErr := authorizeItem(items.Shares, item, userID, model.RoleEditor); authorizeErr != nil {
+		c.AbortWithError(code, authorizeErr)
+		return
+	}
+	entry, findVersionErr := items.Repository.FindVersion(item.ItemId, item.UserId, version)
+	if findVersionErr != nil {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(RevertItemError, "version does not exist"))
+		return
+	}
+	if entry.Action == model.HistoryDelete {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(RevertItemError, "can not revert to a deleted state"))
+		return
+	}
+	itemInput, inputErr := entry.Input()
+	if inputErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(RevertItemError, inputErr.Error()))
+		return
+	}
+	if code, assigneeErr := items.authorizeAssignee(itemInput, item, userID); assigneeErr != nil {
+		c.AbortWithError(code, fmt.Errorf(RevertItemError, assigneeErr.Error()))
+		return
+	}
+	if validateErr := items.validateItemInput(&itemInput, userID, item.UserId, item.ItemId); validateErr != nil {
+		c.AbortWithError(http.StatusConflict, fmt.Errorf(RevertItemError, validateErr.Error()))
+		return
+	}
+	if revertedErr := items.Repository.Revert(&item, &itemInput); revertedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(RevertItemError, revertedErr.Error()))
+		return
+	}
+	reverted, findRevertedErr := items.Repository.Find(itemId, userID)
+	if findRevertedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findRevertedErr.Error()))
+		return
+	}
+	WriteResultWithItem(http.StatusOK, reverted, c)
+}
@@ -3,12 +3,15 @@ package handler
 import (
 	"errors"
 	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/cursor"
 	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/recurrence"
 	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/daniel-vuky/golang-todo-list-v2/storage"
 	"github.com/gin-gonic/gin"
-	ginSession "github.com/go-session/gin-session"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 const DefaultSize int = 100
@@ -22,59 +25,365 @@ const UpdateItemError string = "can not update item, %s"
 const DeleteItemError string = "can not delete item, %s"
 const FindAllItemError string = "can not get the list items, %s"
 const FindItemError string = "can not find the item with ID, %d, %s"
+const DefaultUpcomingDays int = 7
+const CursorConflictError string = "use either after or before, not both"
+const InvalidParentError string = "invalid parent item, %s"
+const InvalidAssigneeError string = "invalid assignee, %s"
+const AssigneeForbiddenError string = "only the owner of the item can change its assignee"
+const InvalidChildrenModeError string = "children must be cascade or reparent"
+const NotRecurringError string = "the item is not recurring"
+const NextOccurrenceError string = "can not create the next occurrence, %s"
+const DefaultOccurrences int = 5
+const ExpandChildren string = "children"
+const ChildrenCascade string = "cascade"
+const ChildrenReparent string = "reparent"
 
 type Items struct {
-	Repository *repository.ItemsRepository
+	Repository  *repository.ItemsRepository
+	Lists       *repository.ListsRepository
+	Users       *repository.UsersRepository
+	Attachments *repository.AttachmentsRepository
+	Blobs       storage.BlobStore
+	Shares      *repository.SharesRepository
+	// ifMatch If-Match header of the request, see withIfMatch
+	ifMatch string
+}
+
+// GetPagination read the page size and the current page from the query
+func GetPagination(c *gin.Context) (int, int) {
+	pageSize, _ := strconv.Atoi(c.Query("size"))
+	if pageSize <= 0 {
+		pageSize = DefaultSize
+	}
+	currentPage, _ := strconv.Atoi(c.Query("p"))
+	if currentPage <= 0 {
+		currentPage = DefaultPage
+	}
+	return pageSize, currentPage
+}
+
+// validateList make sure the list of the item input belongs to the owner of the item
+func (items Items) validateList(itemInput *model.ItemInput, ownerID uint64) error {
+	if itemInput.ListId == nil {
+		return nil
+	}
+	list, findListErr := items.Lists.Find(*itemInput.ListId, ownerID)
+	if findListErr != nil || list.ListId == 0 || list.UserId != ownerID {
+		return fmt.Errorf(FindListError, *itemInput.ListId, "list does not exist")
+	}
+	return nil
+}
+
+// validateParent make sure the parent of the item input belongs to the owner of the item
+// and is neither the item itself nor one of its descendants
+// @param itemID 0 when the item is not created yet
+func (items Items) validateParent(itemInput *model.ItemInput, ownerID, itemID uint64) error {
+	if itemInput.ParentId == nil {
+		return nil
+	}
+	parentID := *itemInput.ParentId
+	if parentID == itemID {
+		return fmt.Errorf(InvalidParentError, "an item can not be its own parent")
+	}
+	parent, findParentErr := items.Repository.Find(int(parentID), ownerID)
+	if findParentErr != nil || parent.ItemId == 0 || parent.UserId != ownerID {
+		return fmt.Errorf(InvalidParentError, "parent item does not exist")
+	}
+	if itemID == 0 {
+		return nil
+	}
+	descendants, findErr := items.Repository.FindDescendants(itemID, ownerID)
+	if findErr != nil {
+		return fmt.Errorf(InvalidParentError, findErr.Error())
+	}
+	for _, descendant := range descendants {
+		if descendant.ItemId == parentID {
+			return fmt.Errorf(InvalidParentError, "an item can not be moved under its own subtask")
+		}
+	}
+	return nil
+}
+
+// authorizeAssignee check the user can give the item to the assignee of the input
+// The assignee can see and change the item once assigned, so only the owner changes it,
+// and the assignee must be the owner or a user the item or its list is shared with
+// @param item the item before the change, only its owner for a new item
+// Return the HTTP status code of the error
+func (items Items) authorizeAssignee(itemInput model.ItemInput, item model.Item, userID uint64) (int, error) {
+	current, next := item.AssigneeId, itemInput.AssigneeId
+	if (current == nil && next == nil) || (current != nil && next != nil && *current == *next) {
+		return http.StatusOK, nil
+	}
+	if item.UserId != userID {
+		return http.StatusForbidden, errors.New(AssigneeForbiddenError)
+	}
+	if next == nil {
+		return http.StatusOK, nil
+	}
+	// The grants of the assignee on the item and on its new list, the current assignee does not count
+	candidate := item
+	candidate.ListId = itemInput.ListId
+	candidate.AssigneeId = nil
+	role, roleErr := items.Shares.Role(candidate, *next)
+	if roleErr != nil {
+		return http.StatusInternalServerError, roleErr
+	}
+	if role == "" {
+		return http.StatusBadRequest, fmt.Errorf(InvalidAssigneeError, "the item is not shared with the user")
+	}
+	return http.StatusOK, nil
+}
+
+// validateItemInput check the references, dates, recurrence and tags of the input
+// and normalize them for the database
+// The dates are read in the timezone of the user, the references must belong to the owner of the item
+// @param itemID 0 when the item is not created yet
+func (items Items) validateItemInput(itemInput *model.ItemInput, userID, ownerID, itemID uint64) error {
+	if listErr := items.validateList(itemInput, ownerID); listErr != nil {
+		return listErr
+	}
+	if parentErr := items.validateParent(itemInput, ownerID, itemID); parentErr != nil {
+		return parentErr
+	}
+	loc := userLocation(items.Users, userID)
+	if datesErr := itemInput.ValidateDates(loc); datesErr != nil {
+		return datesErr
+	}
+	if recurrenceErr := itemInput.ValidateRecurrence(loc); recurrenceErr != nil {
+		return recurrenceErr
+	}
+	return itemInput.ValidateTags()
+}
+
+// userLocation load the timezone of the user, fallback to UTC
+func userLocation(users *repository.UsersRepository, userID uint64) *time.Location {
+	timezone, timezoneErr := users.GetTimezone(userID)
+	if timezoneErr != nil {
+		return time.UTC
+	}
+	loc, locErr := time.LoadLocation(timezone)
+	if locErr != nil {
+		return time.UTC
+	}
+	return loc
 }
 
 // Create create to do item
 func (items Items) Create(c *gin.Context) {
 	var itemInput model.ItemInput
 	bindErr := c.ShouldBindJSON(&itemInput)
-	if bindErr != nil || len(itemInput.Title) == 0 || itemInput.Status == 0 {
+	if bindErr != nil {
 		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
 		return
 	}
-	session := ginSession.FromContext(c)
-	userID, userIDExisted := session.Get("user_id")
+	userID, userIDExisted := GetUserIDFromSession(c)
 	if !userIDExisted {
 		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
 		return
 	}
+	items = items.asActor(c, userID)
+	newItem, code, createErr := items.createItem(itemInput, userID)
+	if createErr != nil {
+		c.AbortWithError(code, createErr)
+		return
+	}
+	WriteResultWithItem(http.StatusOK, newItem, c)
+}
+
+// createItem validate the input and create the item with its tags
+// Return the HTTP status code of the error
+func (items Items) createItem(itemInput model.ItemInput, userID uint64) (model.Item, int, error) {
+	if len(itemInput.Title) == 0 || itemInput.Status == 0 {
+		return model.Item{}, http.StatusBadRequest, errors.New(BindInputError)
+	}
+	ownerID, code, ownerErr := items.newItemOwner(itemInput, userID)
+	if ownerErr != nil {
+		return model.Item{}, code, ownerErr
+	}
+	if code, assigneeErr := items.authorizeAssignee(itemInput, model.Item{UserId: ownerID}, userID); assigneeErr != nil {
+		return model.Item{}, code, assigneeErr
+	}
+	if validateErr := items.validateItemInput(&itemInput, userID, ownerID, 0); validateErr != nil {
+		return model.Item{}, http.StatusBadRequest, validateErr
+	}
 	newItem := model.Item{
-		UserId:      userID.(uint64),
+		UserId:      ownerID,
+		AssigneeId:  itemInput.AssigneeId,
+		ListId:      itemInput.ListId,
+		ParentId:    itemInput.ParentId,
 		Title:       itemInput.Title,
 		Description: itemInput.Description,
 		Status:      itemInput.Status,
+		Priority:    itemInput.Priority,
+		DueAt:       itemInput.DueAt,
+		StartAt:     itemInput.StartAt,
+		Recurrence:  itemInput.Recurrence,
+		Tags:        itemInput.Tags,
 	}
 	insertErr := items.Repository.Insert(&newItem)
 	if insertErr != nil {
-		c.AbortWithError(http.StatusInternalServerError, errors.New(CreateItemError))
-		return
+		return model.Item{}, http.StatusInternalServerError, fmt.Errorf(CreateItemError, insertErr.Error())
 	}
-	WriteResultWithItem(http.StatusOK, newItem, c)
+	if newItem.Tags == nil {
+		newItem.Tags = []string{}
+	}
+	return newItem, http.StatusOK, nil
 }
 
-// List get list to do items
-func (items Items) List(c *gin.Context) {
-	pageSize, _ := strconv.Atoi(c.Query("size"))
-	if pageSize == 0 {
-		pageSize = DefaultSize
+// newItemOwner get the owner of a new item
+// The item belongs to the owner of its parent, or of its list, when they are shared with the user as editor
+// Return the HTTP status code of the error
+func (items Items) newItemOwner(itemInput model.ItemInput, userID uint64) (uint64, int, error) {
+	if itemInput.ParentId != nil {
+		parent, findParentErr := items.Repository.Find(int(*itemInput.ParentId), userID)
+		if findParentErr != nil || parent.ItemId == 0 {
+			return 0, http.StatusBadRequest, fmt.Errorf(InvalidParentError, "parent item does not exist")
+		}
+		if code, authorizeErr := authorizeItem(items.Shares, parent, userID, model.RoleEditor); authorizeErr != nil {
+			return 0, code, authorizeErr
+		}
+		return parent.UserId, http.StatusOK, nil
 	}
-	currentPage, _ := strconv.Atoi(c.Query("p"))
-	if currentPage == 0 {
-		currentPage = DefaultPage
+	if itemInput.ListId != nil {
+		list, findListErr := items.Lists.Find(*itemInput.ListId, userID)
+		if findListErr != nil || list.ListId == 0 {
+			return 0, http.StatusBadRequest, fmt.Errorf(FindListError, *itemInput.ListId, "list does not exist")
+		}
+		if code, authorizeErr := authorizeList(items.Shares, list, userID, model.RoleEditor); authorizeErr != nil {
+			return 0, code, authorizeErr
+		}
+		return list.UserId, http.StatusOK, nil
 	}
-	session := ginSession.FromContext(c)
-	userID, userIDExisted := session.Get("user_id")
+	return userID, http.StatusOK, nil
+}
+
+// List get list to do items with the total count of matching items
+// See ParseItemFilter for the supported filters
+func (items Items) List(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
 	if !userIDExisted {
 		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
 		return
 	}
+	filter, filterErr := ParseItemFilter(c, userID, userLocation(items.Users, userID))
+	if filterErr != nil {
+		c.AbortWithError(http.StatusBadRequest, filterErr)
+		return
+	}
+	items.writeItemPage(c, userID, filter)
+}
+
+// writeItemPage write the page of the items matching the filter with their total count
+// Paginate with ?p=N, or with the returned cursors: ?after=next_cursor and ?before=prev_cursor
+func (items Items) writeItemPage(c *gin.Context, userID uint64, filter repository.ItemFilter) {
+	pageSize, currentPage := GetPagination(c)
+	after, before := c.Query("after"), c.Query("before")
+	if after != "" && before != "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New(CursorConflictError))
+		return
+	}
+	sortKeys := repository.EffectiveSort(filter.Sort)
+	signature := repository.SortSignature(sortKeys)
+	offset := (currentPage - 1) * pageSize
+	if token := after + before; token != "" {
+		values, cursorErr := cursor.Decode(token, signature)
+		if cursorErr != nil {
+			c.AbortWithError(http.StatusBadRequest, cursorErr)
+			return
+		}
+		filter.Keyset = &repository.Keyset{Values: values, Backward: before != ""}
+		offset = 0
+		currentPage = 0
+	}
+	// One more item tells if there is a page after this one
 	listItems, findAllErr := items.Repository.FindAll(
+		pageSize+1,
+		offset,
+		userID,
+		filter,
+	)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllItemError, findAllErr.Error()))
+		return
+	}
+	hasMore := len(listItems) > pageSize
+	if hasMore && before != "" {
+		listItems = listItems[1:]
+	} else if hasMore {
+		listItems = listItems[:pageSize]
+	}
+	total, countErr := items.Repository.CountAll(userID, filter)
+	if countErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllItemError, countErr.Error()))
+		return
+	}
+	page := model.ItemPage{
+		Items: listItems,
+		Total: total,
+		Page:  currentPage,
+		Size:  pageSize,
+	}
+	if len(listItems) > 0 {
+		first := repository.SortValues(listItems[0], sortKeys)
+		last := repository.SortValues(listItems[len(listItems)-1], sortKeys)
+		if (before == "" && hasMore) || before != "" {
+			page.NextCursor = cursor.Encode(signature, last)
+		}
+		if (before != "" && hasMore) || after != "" || currentPage > 1 {
+			page.PrevCursor = cursor.Encode(signature, first)
+		}
+	}
+	if notModified(c, pageETag(page)) {
+		return
+	}
+	WriteResultWithItemPage(http.StatusOK, page, c)
+}
+
+// Today get the not completed items due today in the timezone of the user
+func (items Items) Today(c *gin.Context) {
+	items.listDue(c, func(now, startOfDay time.Time) (time.Time, time.Time) {
+		return startOfDay, startOfDay.AddDate(0, 0, 1)
+	})
+}
+
+// Upcoming get the not completed items due after today
+// Limit the range with ?days=N, default 7 days
+func (items Items) Upcoming(c *gin.Context) {
+	days, _ := strconv.Atoi(c.Query("days"))
+	if days <= 0 {
+		days = DefaultUpcomingDays
+	}
+	items.listDue(c, func(now, startOfDay time.Time) (time.Time, time.Time) {
+		endOfDay := startOfDay.AddDate(0, 0, 1)
+		return endOfDay, endOfDay.AddDate(0, 0, days)
+	})
+}
+
+// Overdue get the not completed items with due date in the past
+func (items Items) Overdue(c *gin.Context) {
+	items.listDue(c, func(now, startOfDay time.Time) (time.Time, time.Time) {
+		return time.Time{}, now
+	})
+}
+
+// listDue write the items due in the range computed from the current time of the user
+// A zero bound means no bound
+func (items Items) listDue(c *gin.Context, dueRange func(now, startOfDay time.Time) (time.Time, time.Time)) {
+	pageSize, currentPage := GetPagination(c)
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	now := time.Now().In(userLocation(items.Users, userID))
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	from, to := dueRange(now, startOfDay)
+	listItems, findAllErr := items.Repository.FindDueBetween(
 		pageSize,
 		(currentPage-1)*pageSize,
-		userID.(uint64),
+		userID,
+		formatBound(from),
+		formatBound(to),
 	)
 	if findAllErr != nil {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllItemError, findAllErr.Error()))
@@ -83,28 +392,51 @@ func (items Items) List(c *gin.Context) {
 	WriteResultWithListItems(http.StatusOK, listItems, c)
 }
 
-// GetByID get to do item by ID
+// formatBound format the bound to the UTC database layout, empty when zero
+func formatBound(bound time.Time) string {
+	if bound.IsZero() {
+		return ""
+	}
+	return bound.UTC().Format(model.DatabaseTimeLayout)
+}
+
+// GetByID get to do item by ID with the progress of its subtasks
+// Include the subtasks tree with ?expand=children
+// Only the subtasks the user can get by ID are included and counted in the progress
 func (items Items) GetByID(c *gin.Context) {
 	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
 	if itemIdErr != nil || itemId == 0 {
 		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
 		return
 	}
-	session := ginSession.FromContext(c)
-	userID, userIDExisted := session.Get("user_id")
+	userID, userIDExisted := GetUserIDFromSession(c)
 	if !userIDExisted {
 		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
 		return
 	}
-	item, findItemErr := items.Repository.Find(itemId, userID.(uint64))
+	item, findItemErr := items.Repository.Find(itemId, userID)
 	if findItemErr != nil || item.ItemId == 0 {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findItemErr.Error()))
 		return
 	}
+	descendants, findErr := items.Repository.FindVisibleDescendants(item.ItemId, userID)
+	if findErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findErr.Error()))
+		return
+	}
+	expanded := c.Query("expand") == ExpandChildren
+	if notModified(c, ItemETag(item, descendants, expanded)) {
+		return
+	}
+	model.BuildTree(&item, descendants)
+	if !expanded {
+		item.Children = nil
+	}
 	WriteResultWithItem(http.StatusOK, item, c)
 }
 
 // UpdateByID update to do item by ID
+// Send the ETag of the item in If-Match to fail with 412 when it was changed since it was read
 func (items Items) UpdateByID(c *gin.Context) {
 	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
 	if itemIdErr != nil || itemId == 0 {
@@ -117,45 +449,196 @@ func (items Items) UpdateByID(c *gin.Context) {
 		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
 		return
 	}
-	session := ginSession.FromContext(c)
-	userID, userIDExisted := session.Get("user_id")
+	userID, userIDExisted := GetUserIDFromSession(c)
 	if !userIDExisted {
 		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
 		return
 	}
-	item, findItemErr := items.Repository.Find(itemId, userID.(uint64))
+	items = items.asActor(c, userID).withIfMatch(c)
+	item, findItemErr := items.Repository.Find(itemId, userID)
 	if findItemErr != nil || item.ItemId == 0 {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findItemErr.Error()))
 		return
 	}
-	if updatedErr := items.Repository.Update(&item, &itemInput); updatedErr != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(UpdateItemError, updatedErr.Error()))
+	if code, authorizeErr := authorizeItem(items.Shares, item, userID, model.RoleEditor); authorizeErr != nil {
+		c.AbortWithError(code, authorizeErr)
+		return
+	}
+	guarded, code, preconditionErr := items.precondition(item)
+	if preconditionErr != nil {
+		c.AbortWithError(code, preconditionErr)
+		return
+	}
+	if code, assigneeErr := items.authorizeAssignee(itemInput, item, userID); assigneeErr != nil {
+		c.AbortWithError(code, assigneeErr)
+		return
+	}
+	if validateErr := items.validateItemInput(&itemInput, userID, item.UserId, item.ItemId); validateErr != nil {
+		c.AbortWithError(http.StatusBadRequest, validateErr)
+		return
+	}
+	// Omitted tags are kept, an empty list removes all the tags
+	if updatedErr := guarded.Update(&item, &itemInput); updatedErr != nil {
+		c.AbortWithError(writeErrorCode(updatedErr), fmt.Errorf(UpdateItemError, updatedErr.Error()))
 		return
 	}
+	if item.Status != model.StatusCompleted && itemInput.Status == model.StatusCompleted && itemInput.Recurrence != nil {
+		if nextErr := items.insertNextOccurrence(item, &itemInput); nextErr != nil {
+			c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(NextOccurrenceError, nextErr.Error()))
+			return
+		}
+	}
+	// The new ETag lets the client chain its next change
+	if updated, findUpdatedErr := items.Repository.Find(itemId, userID); findUpdatedErr == nil {
+		c.Header("ETag", ItemETag(updated, nil, false))
+	}
 	WriteResult(http.StatusOK, "Updated", c)
 }
 
-// DeleteByID delete to do item by item ID
-func (items Items) DeleteByID(c *gin.Context) {
+// insertNextOccurrence create the next occurrence of the recurring item just completed
+// The occurrence follows the due date, or now when the item is late
+// The start date keeps the same distance to the due date
+func (items Items) insertNextOccurrence(item model.Item, itemInput *model.ItemInput) error {
+	after := time.Now()
+	var dueAt, startAt time.Time
+	if itemInput.DueAt != nil {
+		dueAt, _ = time.ParseInLocation(model.DatabaseTimeLayout, *itemInput.DueAt, time.UTC)
+		if dueAt.After(after) {
+			after = dueAt
+		}
+	}
+	if itemInput.StartAt != nil {
+		startAt, _ = time.ParseInLocation(model.DatabaseTimeLayout, *itemInput.StartAt, time.UTC)
+	}
+	next, hasNext := recurrence.Next(*itemInput.Recurrence, after)
+	if !hasNext {
+		return nil
+	}
+	nextDueAt := next.UTC().Format(model.DatabaseTimeLayout)
+	nextItem := model.Item{
+		UserId:      item.UserId,
+		AssigneeId:  itemInput.AssigneeId,
+		ListId:      itemInput.ListId,
+		ParentId:    itemInput.ParentId,
+		Title:       itemInput.Title,
+		Description: itemInput.Description,
+		Status:      model.StatusProcessing,
+		Priority:    itemInput.Priority,
+		DueAt:       &nextDueAt,
+		Recurrence:  itemInput.Recurrence,
+	}
+	if !dueAt.IsZero() && !startAt.IsZero() {
+		nextStartAt := next.Add(-dueAt.Sub(startAt)).UTC().Format(model.DatabaseTimeLayout)
+		nextItem.StartAt = &nextStartAt
+	}
+	return items.Repository.InsertNextOccurrence(item, &nextItem)
+}
+
+// Occurrences preview the next occurrences of a recurring item
+// Set the number of occurrences with ?count=N, default 5
+func (items Items) Occurrences(c *gin.Context) {
 	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
 	if itemIdErr != nil || itemId == 0 {
 		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
 		return
 	}
-	session := ginSession.FromContext(c)
-	userID, userIDExisted := session.Get("user_id")
+	count, _ := strconv.Atoi(c.Query("count"))
+	if count <= 0 {
+		count = DefaultOccurrences
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
 	if !userIDExisted {
 		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
 		return
 	}
-	item, findItemErr := items.Repository.Find(itemId, userID.(uint64))
+	item, findItemErr := items.Repository.Find(itemId, userID)
 	if findItemErr != nil || item.ItemId == 0 {
 		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findItemErr.Error()))
 		return
 	}
-	if deletedErr := items.Repository.Delete(itemId); deletedErr != nil {
-		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(DeleteItemError, deletedErr.Error()))
+	if item.Recurrence == nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(NotRecurringError))
 		return
 	}
+	occurrences, previewErr := recurrence.Preview(*item.Recurrence, time.Now(), count)
+	if previewErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, previewErr)
+		return
+	}
+	loc := userLocation(items.Users, userID)
+	formatted := make([]string, len(occurrences))
+	for i, occurrence := range occurrences {
+		formatted[i] = occurrence.In(loc).Format(time.RFC3339)
+	}
+	WriteResultWithOccurrences(http.StatusOK, formatted, c)
+}
+
+// DeleteByID move to do item to the trash by item ID, or delete it for good with ?permanent=true
+// The subtasks go with it, or are moved to the parent of the item with ?children=reparent
+// The blobs of the attachments of the items deleted for good are removed once the delete is committed
+// Send the ETag of the item in If-Match to fail with 412 when it was changed since it was read
+func (items Items) DeleteByID(c *gin.Context) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	childrenMode := c.DefaultQuery("children", ChildrenCascade)
+	permanent := c.Query("permanent") == "true"
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	items = items.asActor(c, userID).withIfMatch(c)
+	detached, code, deletedErr := items.deleteItem(itemId, userID, childrenMode, permanent)
+	if deletedErr != nil {
+		c.AbortWithError(code, deletedErr)
+		return
+	}
+	if !permanent {
+		WriteResult(http.StatusOK, "Moved to trash", c)
+		return
+	}
+	purgeAttachments(c.Request.Context(), items.Attachments, items.Blobs, detached)
 	WriteResult(http.StatusOK, "Deleted record", c)
 }
+
+// deleteItem move the item of the user to the trash, or delete it for good when permanent is set,
+// with its subtasks according to the children mode
+// Return the attachments detached by a delete for good, to purge once the delete is committed, see purgeAttachments
+// Return the HTTP status code of the error
+func (items Items) deleteItem(itemId int, userID uint64, childrenMode string, permanent bool) ([]uint64, int, error) {
+	if childrenMode != ChildrenCascade && childrenMode != ChildrenReparent {
+		return nil, http.StatusBadRequest, errors.New(InvalidChildrenModeError)
+	}
+	item, findItemErr := items.Repository.Find(itemId, userID)
+	if findItemErr != nil && permanent {
+		item, findItemErr = items.Repository.FindTrashed(itemId, userID)
+	}
+	if findItemErr != nil || item.ItemId == 0 {
+		return nil, http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findItemErr.Error())
+	}
+	if code, authorizeErr := authorizeItem(items.Shares, item, userID, model.RoleOwner); authorizeErr != nil {
+		return nil, code, authorizeErr
+	}
+	guarded, code, preconditionErr := items.precondition(item)
+	if preconditionErr != nil {
+		return nil, code, preconditionErr
+	}
+	if !permanent {
+		if trashedErr := guarded.Trash(item, childrenMode == ChildrenReparent); trashedErr != nil {
+			return nil, writeErrorCode(trashedErr), fmt.Errorf(DeleteItemError, trashedErr.Error())
+		}
+		return nil, http.StatusOK, nil
+	}
+	// An attachment added in the meantime is left to the background purge
+	detached, findErr := items.Attachments.FindIDsOfTree(item.ItemId, childrenMode == ChildrenCascade)
+	if findErr != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf(DeleteItemError, findErr.Error())
+	}
+	if deletedErr := guarded.Delete(item, childrenMode == ChildrenReparent); deletedErr != nil {
+		return nil, writeErrorCode(deletedErr), fmt.Errorf(DeleteItemError, deletedErr.Error())
+	}
+	return detached, http.StatusOK, nil
+}
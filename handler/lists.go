@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const CreateListError string = "can not create list, %s"
+const UpdateListError string = "can not update list, %s"
+const DeleteListError string = "can not delete list, %s"
+const FindAllListError string = "can not get the lists, %s"
+const FindListError string = "can not find the list with ID, %d, %s"
+
+type Lists struct {
+	Repository *repository.ListsRepository
+	Items      *repository.ItemsRepository
+	Shares     *repository.SharesRepository
+}
+
+// findListFromParam load the list of the :id param the logged in user has at least the required role on
+// Abort the request when the list can not be found
+func (lists Lists) findListFromParam(c *gin.Context, required string) (model.List, bool) {
+	listId, listIdErr := strconv.ParseUint(c.Param("id"), 10, 64)
+	if listIdErr != nil || listId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return model.List{}, false
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return model.List{}, false
+	}
+	list, findListErr := lists.Repository.Find(listId, userID)
+	if findListErr != nil || list.ListId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindListError, listId, findListErr))
+		return model.List{}, false
+	}
+	if code, authorizeErr := authorizeList(lists.Shares, list, userID, required); authorizeErr != nil {
+		c.AbortWithError(code, authorizeErr)
+		return model.List{}, false
+	}
+	return list, true
+}
+
+// Create create new list
+func (lists Lists) Create(c *gin.Context) {
+	var listInput model.ListInput
+	bindErr := c.ShouldBindJSON(&listInput)
+	if bindErr != nil || len(listInput.Name) == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	newList := model.List{
+		UserId: userID,
+		Name:   listInput.Name,
+	}
+	if insertErr := lists.Repository.Insert(&newList); insertErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateListError, insertErr.Error()))
+		return
+	}
+	WriteResultWithList(http.StatusOK, newList, c)
+}
+
+// List get all lists of the logged in user and the lists shared with the user
+func (lists Lists) List(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	userLists, findAllErr := lists.Repository.FindAll(userID)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllListError, findAllErr.Error()))
+		return
+	}
+	WriteResultWithLists(http.StatusOK, userLists, c)
+}
+
+// GetByID get list by ID
+func (lists Lists) GetByID(c *gin.Context) {
+	list, found := lists.findListFromParam(c, model.RoleViewer)
+	if !found {
+		return
+	}
+	WriteResultWithList(http.StatusOK, list, c)
+}
+
+// UpdateByID rename list by ID
+func (lists Lists) UpdateByID(c *gin.Context) {
+	var listInput model.ListInput
+	bindErr := c.ShouldBindJSON(&listInput)
+	if bindErr != nil || len(listInput.Name) == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	list, found := lists.findListFromParam(c, model.RoleOwner)
+	if !found {
+		return
+	}
+	if updatedErr := lists.Repository.Update(&list, &listInput); updatedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(UpdateListError, updatedErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Updated", c)
+}
+
+// DeleteByID delete list by ID, the items of the list are kept
+func (lists Lists) DeleteByID(c *gin.Context) {
+	list, found := lists.findListFromParam(c, model.RoleOwner)
+	if !found {
+		return
+	}
+	if deletedErr := lists.Repository.Delete(list.ListId); deletedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(DeleteListError, deletedErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Deleted record", c)
+}
+
+// ListItems get the to do items of the list
+func (lists Lists) ListItems(c *gin.Context) {
+	list, found := lists.findListFromParam(c, model.RoleViewer)
+	if !found {
+		return
+	}
+	pageSize, currentPage := GetPagination(c)
+	listItems, findAllErr := lists.Items.FindAll(
+		pageSize,
+		(currentPage-1)*pageSize,
+		list.UserId,
+		repository.ItemFilter{ListID: list.ListId},
+	)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllItemError, findAllErr.Error()))
+		return
+	}
+	WriteResultWithListItems(http.StatusOK, listItems, c)
+}
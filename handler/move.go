@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const MoveTargetError string = "send either before or after"
+const MoveItemError string = "can not move item, %s"
+
+// Move place the item right before or right after another item of the same owner
+// Only the position of the moved item is rewritten
+func (items Items) Move(c *gin.Context) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	var moveInput model.MoveInput
+	bindErr := c.ShouldBindJSON(&moveInput)
+	if bindErr != nil || (moveInput.Before == nil) == (moveInput.After == nil) {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MoveTargetError))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	items = items.asActor(c, userID)
+	item, findItemErr := items.Repository.Find(itemId, userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findItemErr.Error()))
+		return
+	}
+	if code, authorizeErr := authorizeItem(items.Shares, item, userID, model.RoleEditor); authorizeErr != nil {
+		c.AbortWithError(code, authorizeErr)
+		return
+	}
+	targetID, before := moveInput.After, false
+	if moveInput.Before != nil {
+		targetID, before = moveInput.Before, true
+	}
+	if *targetID == item.ItemId {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(MoveItemError, "an item can not be moved next to itself"))
+		return
+	}
+	target, findTargetErr := items.Repository.Find(int(*targetID), userID)
+	if findTargetErr != nil || target.ItemId == 0 || target.UserId != item.UserId {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(MoveItemError, "target item does not exist"))
+		return
+	}
+	movedErr := items.Repository.Move(&item, target.ItemId, before)
+	if errors.Is(movedErr, repository.ErrMoveTargetNotFound) {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(MoveItemError, movedErr.Error()))
+		return
+	}
+	if movedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(MoveItemError, movedErr.Error()))
+		return
+	}
+	WriteResultWithItem(http.StatusOK, item, c)
+}
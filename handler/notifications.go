@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const FindAllNotificationError string = "can not get the notifications, %s"
+const ReadNotificationError string = "can not mark the notifications as read, %s"
+
+type Notifications struct {
+	Repository *repository.NotificationsRepository
+}
+
+// List get the notifications of the logged in user, the latest first
+// Only the unread notifications with ?unread=true
+func (notifications Notifications) List(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	unread, _ := strconv.ParseBool(c.Query("unread"))
+	pageSize, currentPage := GetPagination(c)
+	userNotifications, findAllErr := notifications.Repository.FindAll(userID, unread, pageSize, (currentPage-1)*pageSize)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllNotificationError, findAllErr.Error()))
+		return
+	}
+	WriteResultWithNotifications(http.StatusOK, userNotifications, c)
+}
+
+// Read mark the notification of the :id param as read, every notification without the param
+func (notifications Notifications) Read(c *gin.Context) {
+	var notificationId uint64
+	if param := c.Param("id"); param != "" {
+		parsed, parseErr := strconv.ParseUint(param, 10, 64)
+		if parseErr != nil || parsed == 0 {
+			c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+			return
+		}
+		notificationId = parsed
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	if readErr := notifications.Repository.MarkRead(notificationId, userID); readErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(ReadNotificationError, readErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Updated", c)
+}
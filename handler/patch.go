@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const InvalidPatchError string = "invalid merge patch, %s"
+
+// patchFields JSON fields accepted by the merge patch, with their target in the input
+// A null value clears the nullable fields and resets the others to their default
+// title and status can not be cleared
+func patchFields(itemInput *model.ItemInput) map[string]any {
+	return map[string]any{
+		"assignee_id": &itemInput.AssigneeId,
+		"list_id":     &itemInput.ListId,
+		"parent_id":   &itemInput.ParentId,
+		"title":       &itemInput.Title,
+		"description": &itemInput.Description,
+		"status":      &itemInput.Status,
+		"priority":    &itemInput.Priority,
+		"due_at":      &itemInput.DueAt,
+		"start_at":    &itemInput.StartAt,
+		"recurrence":  &itemInput.Recurrence,
+		"tags":        &itemInput.Tags,
+	}
+}
+
+// applyMergePatch apply the RFC 7396 merge patch to the input
+// Return the names of the patched fields
+func applyMergePatch(itemInput *model.ItemInput, body []byte) ([]string, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, fmt.Errorf(InvalidPatchError, "the patch must be a JSON object")
+	}
+	targets := patchFields(itemInput)
+	patched := []string{}
+	for field, raw := range patch {
+		target, known := targets[field]
+		if !known {
+			return nil, fmt.Errorf(InvalidPatchError, fmt.Sprintf("unknown field %q", field))
+		}
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			switch field {
+			case "title", "status":
+				return nil, fmt.Errorf(InvalidPatchError, fmt.Sprintf("%s can not be null", field))
+			case "tags":
+				itemInput.Tags = []string{}
+			case "description":
+				itemInput.Description = ""
+			case "priority":
+				itemInput.Priority = model.PriorityNone
+			default:
+				// Unmarshal null into a pointer sets it to nil
+				json.Unmarshal(raw, target)
+			}
+		} else if err := json.Unmarshal(raw, target); err != nil {
+			return nil, fmt.Errorf(InvalidPatchError, fmt.Sprintf("invalid %s", field))
+		}
+		patched = append(patched, field)
+	}
+	if len(itemInput.Title) == 0 || itemInput.Status == 0 {
+		return nil, fmt.Errorf(InvalidPatchError, "title and status can not be empty")
+	}
+	return patched, nil
+}
+
+// patchColumnValue get the database value of the patched field of the validated input
+func patchColumnValue(itemInput *model.ItemInput, field string) any {
+	switch field {
+	case "assignee_id":
+		return itemInput.AssigneeId
+	case "list_id":
+		return itemInput.ListId
+	case "parent_id":
+		return itemInput.ParentId
+	case "title":
+		return itemInput.Title
+	case "description":
+		return itemInput.Description
+	case "status":
+		return itemInput.Status
+	case "priority":
+		return itemInput.Priority
+	case "due_at":
+		return itemInput.DueAt
+	case "start_at":
+		return itemInput.StartAt
+	case "recurrence":
+		return itemInput.Recurrence
+	}
+	return nil
+}
+
+// PatchByID partially update to do item by ID with a JSON merge patch (RFC 7396)
+// Only the fields sent are changed, null clears a field
+// Send the ETag of the item in If-Match to fail with 412 when it was changed since it was read
+func (items Items) PatchByID(c *gin.Context) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	body, bodyErr := c.GetRawData()
+	if bodyErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	items = items.asActor(c, userID).withIfMatch(c)
+	updated, code, patchErr := items.patchItem(itemId, userID, body)
+	if patchErr != nil {
+		c.AbortWithError(code, patchErr)
+		return
+	}
+	c.Header("ETag", ItemETag(updated, nil, false))
+	WriteResultWithItem(http.StatusOK, updated, c)
+}
+
+// patchItem apply the merge patch to the item of the user
+// Return the updated item, or the HTTP status code of the error
+func (items Items) patchItem(itemId int, userID uint64, body []byte) (model.Item, int, error) {
+	item, findItemErr := items.Repository.Find(itemId, userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		return model.Item{}, http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findItemErr.Error())
+	}
+	if code, authorizeErr := authorizeItem(items.Shares, item, userID, model.RoleEditor); authorizeErr != nil {
+		return model.Item{}, code, authorizeErr
+	}
+	guarded, code, preconditionErr := items.precondition(item)
+	if preconditionErr != nil {
+		return model.Item{}, code, preconditionErr
+	}
+	itemInput := item.Input()
+	patched, patchErr := applyMergePatch(&itemInput, body)
+	if patchErr != nil {
+		return model.Item{}, http.StatusBadRequest, patchErr
+	}
+	if code, assigneeErr := items.authorizeAssignee(itemInput, item, userID); assigneeErr != nil {
+		return model.Item{}, code, assigneeErr
+	}
+	if validateErr := items.validateItemInput(&itemInput, userID, item.UserId, item.ItemId); validateErr != nil {
+		return model.Item{}, http.StatusBadRequest, validateErr
+	}
+	fields := map[string]any{}
+	for _, field := range patched {
+		if field != "tags" {
+			fields[field] = patchColumnValue(&itemInput, field)
+		}
+	}
+	if updatedErr := guarded.UpdateFields(item.ItemId, fields, itemInput.Tags); updatedErr != nil {
+		return model.Item{}, writeErrorCode(updatedErr), fmt.Errorf(UpdateItemError, updatedErr.Error())
+	}
+	if item.Status != model.StatusCompleted && itemInput.Status == model.StatusCompleted && itemInput.Recurrence != nil {
+		if nextErr := items.insertNextOccurrence(item, &itemInput); nextErr != nil {
+			return model.Item{}, http.StatusInternalServerError, fmt.Errorf(NextOccurrenceError, nextErr.Error())
+		}
+	}
+	updated, findUpdatedErr := items.Repository.Find(itemId, userID)
+	if findUpdatedErr != nil {
+		return model.Item{}, http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findUpdatedErr.Error())
+	}
+	return updated, http.StatusOK, nil
+}
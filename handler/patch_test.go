@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// patchBase input of an item with every field set
+func patchBase() model.ItemInput {
+	list, parent := uint64(3), uint64(4)
+	dueAt, startAt, recurrence := "2026-10-20 09:00:00", "2026-10-19 09:00:00", "FREQ=WEEKLY"
+	return model.ItemInput{
+		ListId:      &list,
+		ParentId:    &parent,
+		Title:       "Write the report",
+		Description: "Q3 numbers",
+		Status:      model.StatusProcessing,
+		Priority:    model.PriorityHigh,
+		DueAt:       &dueAt,
+		StartAt:     &startAt,
+		Recurrence:  &recurrence,
+		Tags:        []string{"work"},
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantPatched []string
+		want        func(input *model.ItemInput)
+	}{
+		{
+			name:        "empty patch keeps the input",
+			body:        `{}`,
+			wantPatched: []string{},
+			want:        func(input *model.ItemInput) {},
+		},
+		{
+			name:        "set fields",
+			body:        `{"title": "Send the report", "status": 2, "priority": "urgent", "tags": ["work", "q3"]}`,
+			wantPatched: []string{"priority", "status", "tags", "title"},
+			want: func(input *model.ItemInput) {
+				input.Title = "Send the report"
+				input.Status = model.StatusCompleted
+				input.Priority = model.PriorityUrgent
+				input.Tags = []string{"work", "q3"}
+			},
+		},
+		{
+			name:        "null clears the nullable fields",
+			body:        `{"list_id": null, "parent_id": null, "due_at": null, "start_at": null, "recurrence": null}`,
+			wantPatched: []string{"due_at", "list_id", "parent_id", "recurrence", "start_at"},
+			want: func(input *model.ItemInput) {
+				input.ListId = nil
+				input.ParentId = nil
+				input.DueAt = nil
+				input.StartAt = nil
+				input.Recurrence = nil
+			},
+		},
+		{
+			name:        "null resets the other fields to their default",
+			body:        `{"description": null, "priority": null, "tags": null}`,
+			wantPatched: []string{"description", "priority", "tags"},
+			want: func(input *model.ItemInput) {
+				input.Description = ""
+				input.Priority = model.PriorityNone
+				input.Tags = []string{}
+			},
+		},
+		{
+			name:        "set the assignee",
+			body:        `{"assignee_id": 2}`,
+			wantPatched: []string{"assignee_id"},
+			want: func(input *model.ItemInput) {
+				assignee := uint64(2)
+				input.AssigneeId = &assignee
+			},
+		},
+		{
+			name:        "null with spaces",
+			body:        `{"due_at":  null }`,
+			wantPatched: []string{"due_at"},
+			want: func(input *model.ItemInput) {
+				input.DueAt = nil
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := patchBase()
+			patched, err := applyMergePatch(&input, []byte(test.body))
+			if err != nil {
+				t.Fatalf("applyMergePatch(%s): %v", test.body, err)
+			}
+			want := patchBase()
+			test.want(&want)
+			if !reflect.DeepEqual(input, want) {
+				t.Fatalf("applyMergePatch(%s) = %+v, want %+v", test.body, input, want)
+			}
+			sort.Strings(patched)
+			if !reflect.DeepEqual(patched, test.wantPatched) {
+				t.Fatalf("patched fields %v, want %v", patched, test.wantPatched)
+			}
+		})
+	}
+}
+
+func TestApplyMergePatchRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "not an object", body: `["title"]`},
+		{name: "null patch", body: `null`},
+		{name: "invalid JSON", body: `{"title": }`},
+		{name: "unknown field", body: `{"owner": 5}`},
+		{name: "null title", body: `{"title": null}`},
+		{name: "null status", body: `{"status": null}`},
+		{name: "empty title", body: `{"title": ""}`},
+		{name: "zero status", body: `{"status": 0}`},
+		{name: "invalid type", body: `{"list_id": "three"}`},
+		{name: "invalid priority", body: `{"priority": "later"}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			input := patchBase()
+			if _, err := applyMergePatch(&input, []byte(test.body)); err == nil {
+				t.Fatalf("applyMergePatch(%s) succeeded, want an error", test.body)
+			}
+		})
+	}
+}
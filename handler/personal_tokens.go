@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/auth"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const InvalidPersonalTokenError string = "invalid personal access token, %s"
+const CreatePersonalTokenError string = "can not create the personal access token, %s"
+const FindAllPersonalTokenError string = "can not get the personal access tokens, %s"
+const RevokePersonalTokenError string = "can not revoke the personal access token, %s"
+const FindPersonalTokenError string = "can not find the personal access token with ID, %d"
+const InsufficientScopeError string = "the personal access token needs the %s scope"
+const PersonalTokenRouteError string = "the personal access tokens can not access this route"
+
+// authenticatePersonalToken authenticate the request with the personal access token, it needs the scope
+func (users Users) authenticatePersonalToken(tokenString, scope string, c *gin.Context) {
+	token, findErr := users.PersonalTokens.FindByHash(auth.HashPersonalAccessToken(tokenString))
+	if findErr != nil || token.PersonalAccessTokenId == 0 || token.Expired(time.Now()) {
+		Unauthorized(InvalidTokenError, c)
+		return
+	}
+	if scope == "" {
+		Forbidden(PersonalTokenRouteError, "", c)
+		return
+	}
+	if !token.HasScope(scope) {
+		Forbidden(fmt.Sprintf(InsufficientScopeError, scope), scope, c)
+		return
+	}
+	c.Set(UserIDContextKey, token.UserId)
+	if touchErr := users.PersonalTokens.Touch(token.PersonalAccessTokenId); touchErr != nil {
+		fmt.Printf("Fail to update the personal access token, %s\n", touchErr.Error())
+	}
+	c.Next()
+}
+
+// Forbidden abort the request of a bearer token missing the scope of the route
+func Forbidden(message, scope string, c *gin.Context) {
+	challenge := `Bearer realm="api", error="insufficient_scope"`
+	if scope != "" {
+		challenge += fmt.Sprintf(`, scope="%s"`, scope)
+	}
+	c.Header("WWW-Authenticate", challenge)
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"message": message,
+	})
+}
+
+// CreatePersonalToken create a personal access token for the logged in user
+// The secret is only in this response, it is stored by its hash
+func (users Users) CreatePersonalToken(c *gin.Context) {
+	var input model.PersonalAccessTokenInput
+	if bindErr := c.ShouldBind(&input); bindErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	if validateErr := input.Validate(userLocation(users.Repository, userID), time.Now()); validateErr != nil {
+		c.AbortWithError(http.StatusBadRequest, fmt.Errorf(InvalidPersonalTokenError, validateErr.Error()))
+		return
+	}
+	secret, secretErr := auth.NewPersonalAccessToken()
+	if secretErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreatePersonalTokenError, secretErr.Error()))
+		return
+	}
+	token := model.PersonalAccessToken{
+		UserId:    userID,
+		Name:      input.Name,
+		TokenHash: auth.HashPersonalAccessToken(secret),
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if insertErr := users.PersonalTokens.Insert(&token); insertErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreatePersonalTokenError, insertErr.Error()))
+		return
+	}
+	created, findErr := users.PersonalTokens.FindByHash(token.TokenHash)
+	if findErr == nil && created.PersonalAccessTokenId != 0 {
+		token = created
+	}
+	token.Token = secret
+	WriteResultWithPersonalAccessToken(http.StatusCreated, token, c)
+}
+
+// ListPersonalTokens get the personal access tokens of the logged in user, without their secrets
+func (users Users) ListPersonalTokens(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	tokens, findAllErr := users.PersonalTokens.FindAll(userID)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllPersonalTokenError, findAllErr.Error()))
+		return
+	}
+	WriteResultWithPersonalAccessTokens(http.StatusOK, tokens, c)
+}
+
+// DeletePersonalToken revoke the personal access token of the :id param, it is refused right away
+func (users Users) DeletePersonalToken(c *gin.Context) {
+	tokenId, tokenIdErr := strconv.ParseUint(c.Param("id"), 10, 64)
+	if tokenIdErr != nil || tokenId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	revoked, revokeErr := users.PersonalTokens.Revoke(tokenId, userID)
+	if revokeErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(RevokePersonalTokenError, revokeErr.Error()))
+		return
+	}
+	if !revoked {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindPersonalTokenError, tokenId))
+		return
+	}
+	WriteResult(http.StatusOK, "Revoked", c)
+}
@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/auth"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/go-session/session"
+	"hash/fnv"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const RefreshTokenReusedError string = "the refresh token was already used, login again"
+
+// sessionRefreshLocks serialize the renewals of the browser sessions, picked by a hash of the session ID
+// The concurrent requests of an expired session would otherwise use its refresh token twice and revoke it
+// The renewed tokens are saved before the lock is released, the waiting requests use them instead of the used token
+// The sessions live in the memory store of the instance that started them, so a lock of the instance is enough
+var sessionRefreshLocks [64]sync.Mutex
+
+// MaxUserAgentLength size of the user_agent column of the login sessions
+const MaxUserAgentLength int = 255
+
+// startSession record the login of the user from the device of the request, and issue its first token pair
+// The login session follows the new family of refresh tokens
+func (users Users) startSession(user model.User, c *gin.Context) (model.TokenPair, error) {
+	familyID, familyErr := auth.NewTokenFamily()
+	if familyErr != nil {
+		return model.TokenPair{}, familyErr
+	}
+	userAgent := []rune(c.Request.UserAgent())
+	if len(userAgent) > MaxUserAgentLength {
+		userAgent = userAgent[:MaxUserAgentLength]
+	}
+	loginSession := model.LoginSession{
+		UserId:    user.UserId,
+		FamilyId:  familyID,
+		UserAgent: string(userAgent),
+		IpAddress: c.ClientIP(),
+	}
+	if insertErr := users.Sessions.Insert(&loginSession); insertErr != nil {
+		return model.TokenPair{}, insertErr
+	}
+	return users.issueTokenPair(user, familyID, nil)
+}
+
+// issueTokenPair create an access token and a refresh token for the user, in the family of refresh tokens
+// The refresh token is the first of the family, or replaces the used token
+func (users Users) issueTokenPair(user model.User, familyID string, used *model.RefreshToken) (model.TokenPair, error) {
+	accessToken, accessErr := users.Auth.CreateToken(user, familyID)
+	if accessErr != nil {
+		return model.TokenPair{}, accessErr
+	}
+	refreshToken, refreshErr := auth.NewRefreshToken()
+	if refreshErr != nil {
+		return model.TokenPair{}, refreshErr
+	}
+	next := model.RefreshToken{
+		UserId:    user.UserId,
+		FamilyId:  familyID,
+		TokenHash: auth.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().UTC().Add(auth.RefreshTokenLifetime).Format(model.DatabaseTimeLayout),
+	}
+	if used != nil {
+		if rotateErr := users.RefreshTokens.Rotate(*used, &next); rotateErr != nil {
+			return model.TokenPair{}, rotateErr
+		}
+	} else {
+		if insertErr := users.RefreshTokens.Insert(&next); insertErr != nil {
+			return model.TokenPair{}, insertErr
+		}
+	}
+	return model.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(auth.AccessTokenLifetime.Seconds()),
+	}, nil
+}
+
+// rotate exchange the refresh token for a new token pair, the refresh token can not be used again
+// Using a refresh token twice revokes its whole family, so a stolen copy stops working for both holders
+// Return the HTTP status code of the error
+func (users Users) rotate(refreshToken string) (model.TokenPair, int, error) {
+	used, findErr := users.RefreshTokens.FindByHash(auth.HashRefreshToken(refreshToken))
+	if findErr != nil || used.RefreshTokenId == 0 || used.RevokedAt != nil || used.Expired(time.Now()) {
+		return model.TokenPair{}, http.StatusUnauthorized, errors.New(InvalidTokenError)
+	}
+	if used.UsedAt != nil {
+		return users.revokeReused(used)
+	}
+	user, findUserErr := users.Repository.FindByID(used.UserId)
+	if findUserErr != nil || user.UserId == 0 {
+		return model.TokenPair{}, http.StatusUnauthorized, errors.New(InvalidTokenError)
+	}
+	pair, pairErr := users.issueTokenPair(user, used.FamilyId, &used)
+	if errors.Is(pairErr, repository.ErrRefreshTokenReused) {
+		return users.revokeReused(used)
+	}
+	if pairErr != nil {
+		return model.TokenPair{}, http.StatusInternalServerError, fmt.Errorf(CreateTokenError, pairErr.Error())
+	}
+	return pair, http.StatusOK, nil
+}
+
+// revokeReused revoke the family of a refresh token used twice
+// Return the HTTP status code of the error
+func (users Users) revokeReused(used model.RefreshToken) (model.TokenPair, int, error) {
+	if revokeErr := users.RefreshTokens.RevokeFamily(used.FamilyId); revokeErr != nil {
+		return model.TokenPair{}, http.StatusInternalServerError, revokeErr
+	}
+	return model.TokenPair{}, http.StatusUnauthorized, errors.New(RefreshTokenReusedError)
+}
+
+// revokeRefreshToken revoke the family of the refresh token, unknown tokens are ignored
+func (users Users) revokeRefreshToken(refreshToken string) error {
+	token, findErr := users.RefreshTokens.FindByHash(auth.HashRefreshToken(refreshToken))
+	if findErr != nil || token.RefreshTokenId == 0 {
+		return nil
+	}
+	return users.RefreshTokens.RevokeFamily(token.FamilyId)
+}
+
+// refreshSession renew the tokens of the browser session with its refresh token
+// Return false when the session can not be renewed
+func (users Users) refreshSession(store session.Store) bool {
+	hash := fnv.New32a()
+	hash.Write([]byte(store.SessionID()))
+	lock := &sessionRefreshLocks[hash.Sum32()%uint32(len(sessionRefreshLocks))]
+	lock.Lock()
+	defer lock.Unlock()
+
+	// A concurrent request of the session may have renewed it in the meantime
+	if tokenString, existed := store.Get("token"); existed {
+		if token, err := users.Auth.ParseToken(tokenString.(string)); err == nil && token.Valid {
+			return true
+		}
+	}
+	refreshToken, existed := store.Get("refresh_token")
+	if !existed {
+		return false
+	}
+	pair, _, rotateErr := users.rotate(refreshToken.(string))
+	if rotateErr != nil {
+		return false
+	}
+	store.Set("token", pair.AccessToken)
+	store.Set("refresh_token", pair.RefreshToken)
+	return store.Save() == nil
+}
+
+// Refresh exchange the refresh token for a new token pair, for the API clients
+// The refresh token is rotated, keep the new one
+func (users Users) Refresh(c *gin.Context) {
+	var refreshInput model.RefreshInput
+	if bindErr := c.ShouldBind(&refreshInput); bindErr != nil || refreshInput.RefreshToken == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	pair, code, rotateErr := users.rotate(refreshInput.RefreshToken)
+	if code == http.StatusUnauthorized {
+		Unauthorized(rotateErr.Error(), c)
+		return
+	}
+	if rotateErr != nil {
+		c.AbortWithError(code, rotateErr)
+		return
+	}
+	WriteResultWithTokenPair(http.StatusOK, pair, c)
+}
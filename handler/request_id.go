@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/gin-gonic/gin"
+	"regexp"
+)
+
+const RequestIDHeader string = "X-Request-ID"
+
+// requestIDPattern request IDs accepted from the clients, others are replaced
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID middleware give every request an ID, taken from the X-Request-ID header when valid
+// The ID is sent back in the same header
+func RequestID(c *gin.Context) {
+	requestID := c.GetHeader(RequestIDHeader)
+	if !requestIDPattern.MatchString(requestID) {
+		random := make([]byte, 16)
+		rand.Read(random)
+		requestID = hex.EncodeToString(random)
+	}
+	c.Set("request_id", requestID)
+	c.Header(RequestIDHeader, requestID)
+	c.Next()
+}
+
+// GetRequestIDFromContext get the ID given to the request by RequestID
+func GetRequestIDFromContext(c *gin.Context) string {
+	return c.GetString("request_id")
+}
@@ -21,11 +21,113 @@ func WriteResultWithListItems(code int, result ListItem, c *gin.Context) {
 	c.JSON(code, result)
 }
 
+// WriteResultWithItemPage write the result code and page of items to the gin context
+func WriteResultWithItemPage(code int, result model.ItemPage, c *gin.Context) {
+	c.JSON(code, result)
+}
+
 // WriteResultWithItem write the result code and result to the gin context
 func WriteResultWithItem(code int, result model.Item, c *gin.Context) {
 	c.JSON(code, result)
 }
 
+// WriteResultWithList write the result code and list to the gin context
+func WriteResultWithList(code int, result model.List, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithLists write the result code and lists to the gin context
+func WriteResultWithLists(code int, result []model.List, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithTag write the result code and tag to the gin context
+func WriteResultWithTag(code int, result model.Tag, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithTags write the result code and tags to the gin context
+func WriteResultWithTags(code int, result []model.Tag, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithOccurrences write the result code and occurrences to the gin context
+func WriteResultWithOccurrences(code int, result []string, c *gin.Context) {
+	c.JSON(code, gin.H{
+		"occurrences": result,
+	})
+}
+
+// WriteResultWithSearchResults write the result code and search results to the gin context
+func WriteResultWithSearchResults(code int, result []model.SearchResult, c *gin.Context) {
+	c.JSON(code, result)
+}
+
 func Redirect(path string, errorCode int, c *gin.Context) {
 	c.Redirect(http.StatusFound, fmt.Sprintf("/%s?error=%d", path, errorCode))
 }
+
+// WriteResultWithBulkResult write the result code and bulk operation results to the gin context
+func WriteResultWithBulkResult(code int, result model.BulkResult, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithHistory write the result code and history entries to the gin context
+func WriteResultWithHistory(code int, result []model.HistoryEntry, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithComment write the result code and comment to the gin context
+func WriteResultWithComment(code int, result model.Comment, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithComments write the result code and comments to the gin context
+func WriteResultWithComments(code int, result []model.Comment, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithAttachment write the result code and attachment to the gin context
+func WriteResultWithAttachment(code int, result model.Attachment, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithAttachments write the result code and attachments to the gin context
+func WriteResultWithAttachments(code int, result []model.Attachment, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithShare write the result code and share to the gin context
+func WriteResultWithShare(code int, result model.Share, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithShares write the result code and shares to the gin context
+func WriteResultWithShares(code int, result []model.Share, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithNotifications write the result code and notifications to the gin context
+func WriteResultWithNotifications(code int, result []model.Notification, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithTokenPair write the result code and token pair to the gin context
+func WriteResultWithTokenPair(code int, result model.TokenPair, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithLoginSessions write the result code and login sessions to the gin context
+func WriteResultWithLoginSessions(code int, result []model.LoginSession, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithPersonalAccessToken write the result code and personal access token to the gin context
+func WriteResultWithPersonalAccessToken(code int, result model.PersonalAccessToken, c *gin.Context) {
+	c.JSON(code, result)
+}
+
+// WriteResultWithPersonalAccessTokens write the result code and personal access tokens to the gin context
+func WriteResultWithPersonalAccessTokens(code int, result []model.PersonalAccessToken, c *gin.Context) {
+	c.JSON(code, result)
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"html"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const SearchItemError string = "can not search the items, %s"
+const MissingSearchQuery string = "please enter the search query q"
+
+// SnippetRadius number of bytes kept around the first match of the snippet
+const SnippetRadius int = 80
+
+// Search search the to do items by title and description
+// Support "quoted phrases", -excluded words and status:done in ?q=
+func (items Items) Search(c *gin.Context) {
+	rawQuery := strings.TrimSpace(c.Query("q"))
+	if rawQuery == "" {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingSearchQuery))
+		return
+	}
+	query, queryErr := repository.ParseSearchQuery(rawQuery)
+	if queryErr != nil {
+		c.AbortWithError(http.StatusBadRequest, queryErr)
+		return
+	}
+	pageSize, currentPage := GetPagination(c)
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	results, searchErr := items.Repository.Search(
+		pageSize,
+		(currentPage-1)*pageSize,
+		userID,
+		query,
+	)
+	if searchErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(SearchItemError, searchErr.Error()))
+		return
+	}
+	matcher := termsMatcher(query.Terms())
+	for i := range results {
+		results[i].Highlight.Title = highlight(results[i].Title, matcher)
+		results[i].Highlight.Snippet = highlight(snippet(results[i].Description, matcher), matcher)
+	}
+	WriteResultWithSearchResults(http.StatusOK, results, c)
+}
+
+// termsMatcher build the case insensitive regexp matching any of the terms
+func termsMatcher(terms []string) *regexp.Regexp {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = strings.ReplaceAll(regexp.QuoteMeta(term), " ", `\s+`)
+	}
+	return regexp.MustCompile(`(?i)(` + strings.Join(quoted, "|") + `)`)
+}
+
+// highlight escape the text and wrap the matches in <mark>
+func highlight(text string, matcher *regexp.Regexp) string {
+	builder := strings.Builder{}
+	last := 0
+	for _, match := range matcher.FindAllStringIndex(text, -1) {
+		builder.WriteString(html.EscapeString(text[last:match[0]]))
+		builder.WriteString("<mark>")
+		builder.WriteString(html.EscapeString(text[match[0]:match[1]]))
+		builder.WriteString("</mark>")
+		last = match[1]
+	}
+	builder.WriteString(html.EscapeString(text[last:]))
+	return builder.String()
+}
+
+// snippet cut the text around its first match
+func snippet(text string, matcher *regexp.Regexp) string {
+	start, end := 0, len(text)
+	if match := matcher.FindStringIndex(text); match != nil {
+		start, end = match[0]-SnippetRadius, match[1]+SnippetRadius
+	} else {
+		end = 2 * SnippetRadius
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > len(text) {
+		end = len(text)
+	}
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+	cut := text[start:end]
+	if start > 0 {
+		cut = "…" + cut
+	}
+	if end < len(text) {
+		cut += "…"
+	}
+	return cut
+}
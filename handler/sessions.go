@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const FindAllLoginSessionError string = "can not get the sessions, %s"
+const FindLoginSessionError string = "can not find the session with ID, %d"
+const RevokeLoginSessionError string = "can not sign out the session, %s"
+
+// LoginSessionContextKey key of the login session of the access token in the gin context
+const LoginSessionContextKey string = "auth_login_session"
+
+// touchSession keep the login session of the access token in the context, and write when it was last seen
+// The tokens issued before the login sessions have none
+func (users Users) touchSession(sessionID string, c *gin.Context) {
+	if sessionID == "" {
+		return
+	}
+	c.Set(LoginSessionContextKey, sessionID)
+	if touchErr := users.Sessions.Touch(sessionID, c.ClientIP()); touchErr != nil {
+		fmt.Printf("Fail to update the session, %s\n", touchErr.Error())
+	}
+}
+
+// ListSessions get the devices the logged in user is logged in from, the last seen first
+// The session of the request is marked as current
+func (users Users) ListSessions(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	loginSessions, findAllErr := users.Sessions.FindAll(userID)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllLoginSessionError, findAllErr.Error()))
+		return
+	}
+	current := c.GetString(LoginSessionContextKey)
+	for index := range loginSessions {
+		loginSessions[index].Current = current != "" && loginSessions[index].FamilyId == current
+	}
+	WriteResultWithLoginSessions(http.StatusOK, loginSessions, c)
+}
+
+// DeleteSession sign out the device of the :id param
+// Its refresh tokens are revoked and its access tokens are refused right away
+func (users Users) DeleteSession(c *gin.Context) {
+	loginSessionId, loginSessionIdErr := strconv.ParseUint(c.Param("id"), 10, 64)
+	if loginSessionIdErr != nil || loginSessionId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	loginSession, findErr := users.Sessions.FindByID(loginSessionId, userID)
+	if findErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllLoginSessionError, findErr.Error()))
+		return
+	}
+	if loginSession.LoginSessionId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindLoginSessionError, loginSessionId))
+		return
+	}
+	if revokeErr := users.RefreshTokens.RevokeFamily(loginSession.FamilyId); revokeErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(RevokeLoginSessionError, revokeErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Signed out", c)
+}
@@ -0,0 +1,271 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const ShareForbiddenError string = "you need the %s role on it"
+const ShareTargetError string = "share either an item_id or a list_id"
+const ShareRoleError string = "role must be viewer, editor or owner"
+const ShareInviteeError string = "can not find a user with this username or email"
+const ShareSelfError string = "can not share with the owner"
+const CreateShareError string = "can not share, %s"
+const AcceptShareError string = "can not accept the invitation, %s"
+const DeleteShareError string = "can not revoke the share, %s"
+const FindAllShareError string = "can not get the shares, %s"
+const FindShareError string = "can not find the share with ID, %d, %s"
+
+type Shares struct {
+	Repository *repository.SharesRepository
+	Items      *repository.ItemsRepository
+	Lists      *repository.ListsRepository
+	Users      *repository.UsersRepository
+}
+
+// authorizeItem check the user has at least the required role on the item
+// Return the HTTP status code of the error
+func authorizeItem(sharesRepository *repository.SharesRepository, item model.Item, userID uint64, required string) (int, error) {
+	role, roleErr := sharesRepository.Role(item, userID)
+	if roleErr != nil {
+		return http.StatusInternalServerError, roleErr
+	}
+	if !model.RoleAllows(role, required) {
+		return http.StatusForbidden, fmt.Errorf(ShareForbiddenError, required)
+	}
+	return http.StatusOK, nil
+}
+
+// authorizeList check the user has at least the required role on the list
+// Return the HTTP status code of the error
+func authorizeList(sharesRepository *repository.SharesRepository, list model.List, userID uint64, required string) (int, error) {
+	role, roleErr := sharesRepository.ListRole(list, userID)
+	if roleErr != nil {
+		return http.StatusInternalServerError, roleErr
+	}
+	if !model.RoleAllows(role, required) {
+		return http.StatusForbidden, fmt.Errorf(ShareForbiddenError, required)
+	}
+	return http.StatusOK, nil
+}
+
+// authorizeTarget load the item or the list shared and check the user has at least the required role on it
+// Return the owner of the item or the list, or the HTTP status code of the error
+func (shares Shares) authorizeTarget(itemID, listID *uint64, userID uint64, required string) (uint64, int, error) {
+	if (itemID == nil) == (listID == nil) {
+		return 0, http.StatusBadRequest, errors.New(ShareTargetError)
+	}
+	if itemID != nil {
+		item, findItemErr := shares.Items.Find(int(*itemID), userID)
+		if findItemErr != nil || item.ItemId == 0 {
+			return 0, http.StatusNotFound, fmt.Errorf(FindItemError, *itemID, findItemErr)
+		}
+		code, authorizeErr := authorizeItem(shares.Repository, item, userID, required)
+		return item.UserId, code, authorizeErr
+	}
+	list, findListErr := shares.Lists.Find(*listID, userID)
+	if findListErr != nil || list.ListId == 0 {
+		return 0, http.StatusNotFound, fmt.Errorf(FindListError, *listID, findListErr)
+	}
+	code, authorizeErr := authorizeList(shares.Repository, list, userID, required)
+	return list.UserId, code, authorizeErr
+}
+
+// findShareFromParam load the share of the :id param
+// Abort the request when the share can not be found
+func (shares Shares) findShareFromParam(c *gin.Context) (model.Share, uint64, bool) {
+	shareId, shareIdErr := strconv.ParseUint(c.Param("id"), 10, 64)
+	if shareIdErr != nil || shareId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return model.Share{}, 0, false
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return model.Share{}, 0, false
+	}
+	share, findShareErr := shares.Repository.Find(shareId)
+	if findShareErr != nil || share.ShareId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindShareError, shareId, findShareErr))
+		return model.Share{}, 0, false
+	}
+	return share, userID, true
+}
+
+// queryID read an optional ID from the query, nil when it is not set
+func queryID(c *gin.Context, name string) (*uint64, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, idErr := strconv.ParseUint(value, 10, 64)
+	if idErr != nil || id == 0 {
+		return nil, fmt.Errorf("invalid %s", name)
+	}
+	return &id, nil
+}
+
+// List get the grants on the item of ?item_id=N or on the list of ?list_id=N
+func (shares Shares) List(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	itemID, itemIDErr := queryID(c, "item_id")
+	listID, listIDErr := queryID(c, "list_id")
+	if itemIDErr != nil || listIDErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(ShareTargetError))
+		return
+	}
+	if _, code, authorizeErr := shares.authorizeTarget(itemID, listID, userID, model.RoleViewer); authorizeErr != nil {
+		c.AbortWithError(code, authorizeErr)
+		return
+	}
+	var listShares []model.Share
+	var findAllErr error
+	if itemID != nil {
+		listShares, findAllErr = shares.Repository.FindByItem(*itemID)
+	} else {
+		listShares, findAllErr = shares.Repository.FindByList(*listID)
+	}
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllShareError, findAllErr.Error()))
+		return
+	}
+	WriteResultWithShares(http.StatusOK, listShares, c)
+}
+
+// Create invite a user, by username or email, to an item or a list
+// Only the users with the owner role can share, the grant gives access once accepted
+func (shares Shares) Create(c *gin.Context) {
+	var shareInput model.ShareInput
+	if bindErr := c.ShouldBindJSON(&shareInput); bindErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return
+	}
+	if shareInput.Role == "" {
+		shareInput.Role = model.RoleViewer
+	}
+	if !model.ValidRole(shareInput.Role) {
+		c.AbortWithError(http.StatusBadRequest, errors.New(ShareRoleError))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	ownerID, code, authorizeErr := shares.authorizeTarget(shareInput.ItemId, shareInput.ListId, userID, model.RoleOwner)
+	if authorizeErr != nil {
+		c.AbortWithError(code, authorizeErr)
+		return
+	}
+	invitee, findInviteeErr := shares.Users.FindByLogin(strings.TrimSpace(shareInput.Invitee))
+	if findInviteeErr != nil || invitee.UserId == 0 {
+		c.AbortWithError(http.StatusNotFound, errors.New(ShareInviteeError))
+		return
+	}
+	if invitee.UserId == ownerID || invitee.UserId == userID {
+		c.AbortWithError(http.StatusBadRequest, errors.New(ShareSelfError))
+		return
+	}
+	newShare := model.Share{
+		ItemId:    shareInput.ItemId,
+		ListId:    shareInput.ListId,
+		OwnerId:   ownerID,
+		GranteeId: invitee.UserId,
+		Role:      shareInput.Role,
+		InvitedBy: userID,
+	}
+	if insertErr := shares.Repository.Insert(&newShare); insertErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateShareError, insertErr.Error()))
+		return
+	}
+	shares.writeShare(c, newShare.ShareId)
+}
+
+// Invitations get the invitations of the logged in user waiting to be accepted
+func (shares Shares) Invitations(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	invitations, findAllErr := shares.Repository.FindInvitations(userID)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllShareError, findAllErr.Error()))
+		return
+	}
+	WriteResultWithShares(http.StatusOK, invitations, c)
+}
+
+// Accept accept an invitation, only by the invited user
+func (shares Shares) Accept(c *gin.Context) {
+	share, userID, found := shares.findShareFromParam(c)
+	if !found {
+		return
+	}
+	if share.GranteeId != userID {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindShareError, share.ShareId, "not invited"))
+		return
+	}
+	if acceptedErr := shares.Repository.Accept(share.ShareId); acceptedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(AcceptShareError, acceptedErr.Error()))
+		return
+	}
+	shares.writeShare(c, share.ShareId)
+}
+
+// DeleteByID revoke the share, by a user with the owner role,
+// or decline the invitation or leave the share by the invited user
+func (shares Shares) DeleteByID(c *gin.Context) {
+	share, userID, found := shares.findShareFromParam(c)
+	if !found {
+		return
+	}
+	if share.GranteeId != userID {
+		if _, code, authorizeErr := shares.authorizeTarget(share.ItemId, share.ListId, userID, model.RoleOwner); authorizeErr != nil {
+			c.AbortWithError(code, authorizeErr)
+			return
+		}
+	}
+	if deletedErr := shares.Repository.Delete(share.ShareId); deletedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(DeleteShareError, deletedErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Deleted record", c)
+}
+
+// writeShare reload the share and write it
+func (shares Shares) writeShare(c *gin.Context, shareId uint64) {
+	saved, findErr := shares.Repository.Find(shareId)
+	if findErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindShareError, shareId, findErr.Error()))
+		return
+	}
+	WriteResultWithShare(http.StatusOK, saved, c)
+}
+
+// Shared get the items shared with the logged in user, directly or by their list
+// Accept the filters and the pagination of List
+func (items Items) Shared(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	filter, filterErr := ParseItemFilter(c, userID, userLocation(items.Users, userID))
+	if filterErr != nil {
+		c.AbortWithError(http.StatusBadRequest, filterErr)
+		return
+	}
+	filter.Shared = true
+	items.writeItemPage(c, userID, filter)
+}
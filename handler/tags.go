@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const CreateTagError string = "can not create tag, %s"
+const UpdateTagError string = "can not update tag, %s"
+const DeleteTagError string = "can not delete tag, %s"
+const FindAllTagError string = "can not get the tags, %s"
+const FindTagError string = "can not find the tag with ID, %d, %v"
+const TagExistedError string = "a tag with this name was existed"
+
+type Tags struct {
+	Repository *repository.TagsRepository
+}
+
+// findTagFromParam load the tag of the logged in user from the :id param
+// Abort the request when the tag can not be found
+func (tags Tags) findTagFromParam(c *gin.Context) (model.Tag, bool) {
+	tagId, tagIdErr := strconv.ParseUint(c.Param("id"), 10, 64)
+	if tagIdErr != nil || tagId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return model.Tag{}, false
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return model.Tag{}, false
+	}
+	tag, findTagErr := tags.Repository.Find(tagId, userID)
+	if findTagErr != nil || tag.TagId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindTagError, tagId, findTagErr))
+		return model.Tag{}, false
+	}
+	return tag, true
+}
+
+// bindTagInput bind and normalize the tag input
+func bindTagInput(c *gin.Context) (model.TagInput, bool) {
+	var tagInput model.TagInput
+	if bindErr := c.ShouldBindJSON(&tagInput); bindErr != nil {
+		c.AbortWithError(http.StatusBadRequest, errors.New(BindInputError))
+		return tagInput, false
+	}
+	name, nameErr := model.NormalizeTag(tagInput.Name)
+	if nameErr != nil {
+		c.AbortWithError(http.StatusBadRequest, nameErr)
+		return tagInput, false
+	}
+	tagInput.Name = name
+	return tagInput, true
+}
+
+// Create create new tag
+func (tags Tags) Create(c *gin.Context) {
+	tagInput, valid := bindTagInput(c)
+	if !valid {
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	newTag := model.Tag{
+		UserId: userID,
+		Name:   tagInput.Name,
+	}
+	if insertErr := tags.Repository.Insert(&newTag); insertErr != nil {
+		if repository.IsDuplicateError(insertErr) {
+			c.AbortWithError(http.StatusConflict, errors.New(TagExistedError))
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(CreateTagError, insertErr.Error()))
+		return
+	}
+	WriteResultWithTag(http.StatusOK, newTag, c)
+}
+
+// List get all tags of the logged in user with their usage counts
+func (tags Tags) List(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	userTags, findAllErr := tags.Repository.FindAll(userID)
+	if findAllErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindAllTagError, findAllErr.Error()))
+		return
+	}
+	WriteResultWithTags(http.StatusOK, userTags, c)
+}
+
+// GetByID get tag by ID
+func (tags Tags) GetByID(c *gin.Context) {
+	tag, found := tags.findTagFromParam(c)
+	if !found {
+		return
+	}
+	WriteResultWithTag(http.StatusOK, tag, c)
+}
+
+// UpdateByID rename tag by ID
+func (tags Tags) UpdateByID(c *gin.Context) {
+	tagInput, valid := bindTagInput(c)
+	if !valid {
+		return
+	}
+	tag, found := tags.findTagFromParam(c)
+	if !found {
+		return
+	}
+	if updatedErr := tags.Repository.Update(&tag, &tagInput); updatedErr != nil {
+		if repository.IsDuplicateError(updatedErr) {
+			c.AbortWithError(http.StatusConflict, errors.New(TagExistedError))
+			return
+		}
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(UpdateTagError, updatedErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Updated", c)
+}
+
+// DeleteByID delete tag by ID and remove it from the items
+func (tags Tags) DeleteByID(c *gin.Context) {
+	tag, found := tags.findTagFromParam(c)
+	if !found {
+		return
+	}
+	if deletedErr := tags.Repository.Delete(tag.TagId); deletedErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(DeleteTagError, deletedErr.Error()))
+		return
+	}
+	WriteResult(http.StatusOK, "Deleted record", c)
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/repository"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+const RestoreItemError string = "can not restore item, %s"
+
+// Trash get the items in the trash, the most recently deleted first
+// Accept the filters and the pagination of List
+func (items Items) Trash(c *gin.Context) {
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	filter, filterErr := ParseItemFilter(c, userID, userLocation(items.Users, userID))
+	if filterErr != nil {
+		c.AbortWithError(http.StatusBadRequest, filterErr)
+		return
+	}
+	filter.Trashed = true
+	if len(filter.Sort) == 0 {
+		filter.Sort = []repository.SortKey{{Column: "deleted_at", Desc: true}}
+	}
+	items.writeItemPage(c, userID, filter)
+}
+
+// Restore take the item out of the trash with the subtasks deleted along with it
+func (items Items) Restore(c *gin.Context) {
+	itemId, itemIdErr := strconv.Atoi(c.Param("id"))
+	if itemIdErr != nil || itemId == 0 {
+		c.AbortWithError(http.StatusBadRequest, errors.New(MissingInputID))
+		return
+	}
+	userID, userIDExisted := GetUserIDFromSession(c)
+	if !userIDExisted {
+		c.AbortWithError(http.StatusForbidden, errors.New(SessionError))
+		return
+	}
+	items = items.asActor(c, userID)
+	item, findItemErr := items.Repository.FindTrashed(itemId, userID)
+	if findItemErr != nil || item.ItemId == 0 {
+		c.AbortWithError(http.StatusNotFound, fmt.Errorf(FindItemError, itemId, "the item is not in the trash"))
+		return
+	}
+	if restoredErr := items.Repository.Restore(item); restoredErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(RestoreItemError, restoredErr.Error()))
+		return
+	}
+	restored, findRestoredErr := items.Repository.Find(itemId, userID)
+	if findRestoredErr != nil {
+		c.AbortWithError(http.StatusInternalServerError, fmt.Errorf(FindItemError, itemId, findRestoredErr.Error()))
+		return
+	}
+	WriteResultWithItem(http.StatusOK, restored, c)
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	_ "time/tzdata"
 )
 
 func main() {
@@ -0,0 +1,34 @@
+package markdown
+
+import (
+	"bytes"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"regexp"
+)
+
+// MaxLength maximum length of a Markdown source in bytes
+const MaxLength int = 10000
+
+// renderer Markdown with the GitHub extensions: tables, strikethrough, autolinks and task lists
+var renderer = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// policy HTML allowed in the rendered Markdown, scripts, styles and event handlers are removed
+var policy = func() *bluemonday.Policy {
+	ugc := bluemonday.UGCPolicy()
+	ugc.RequireNoFollowOnLinks(true)
+	ugc.AddTargetBlankToFullyQualifiedLinks(true)
+	ugc.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	ugc.AllowAttrs("checked", "disabled").OnElements("input")
+	return ugc
+}()
+
+// Render convert the Markdown source to sanitized HTML
+func Render(source string) (string, error) {
+	var html bytes.Buffer
+	if err := renderer.Convert([]byte(source), &html); err != nil {
+		return "", err
+	}
+	return policy.Sanitize(html.String()), nil
+}
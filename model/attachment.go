@@ -0,0 +1,13 @@
+package model
+
+// Attachment file attached to an item, its content is kept in the blob store under StorageKey
+type Attachment struct {
+	AttachmentId uint64 `json:"attachment_id"`
+	ItemId       uint64 `json:"item_id"`
+	UserId       uint64 `json:"user_id"`
+	Filename     string `json:"filename"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	StorageKey   string `json:"-"`
+	CreatedAt    string `json:"created_at"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import "encoding/json"
+
+const BulkCreate string = "create"
+const BulkUpdate string = "update"
+const BulkDelete string = "delete"
+
+const BulkStatusOK string = "ok"
+const BulkStatusFailed string = "failed"
+const BulkStatusRolledBack string = "rolled_back"
+const BulkStatusSkipped string = "skipped"
+
+// BulkRequest operations run in a single transaction
+// With Atomic the first failure rolls back the whole batch
+type BulkRequest struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations"`
+}
+
+// BulkOperation create with Item, update with the merge patch Patch, delete with Children and Permanent
+type BulkOperation struct {
+	Op        string          `json:"op"`
+	Id        uint64          `json:"id"`
+	Item      *ItemInput      `json:"item"`
+	Patch     json.RawMessage `json:"patch"`
+	Children  string          `json:"children"`
+	Permanent bool            `json:"permanent"`
+}
+
+type BulkResult struct {
+	Committed bool                  `json:"committed"`
+	Results   []BulkOperationResult `json:"results"`
+}
+
+type BulkOperationResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status string `json:"status"`
+	ItemId uint64 `json:"item_id,omitempty"`
+	Item   *Item  `json:"item,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,17 @@
+package model
+
+// Comment Body is Markdown, Html is the sanitized rendering of it
+type Comment struct {
+	CommentId uint64 `json:"comment_id"`
+	ItemId    uint64 `json:"item_id"`
+	UserId    uint64 `json:"user_id"`
+	Username  string `json:"username"`
+	Body      string `json:"body"`
+	Html      string `json:"html"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type CommentInput struct {
+	Body string `json:"body"`
+}
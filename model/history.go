@@ -0,0 +1,106 @@
+package model
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+const HistoryCreate string = "create"
+const HistoryUpdate string = "update"
+const HistoryMove string = "move"
+const HistoryArchive string = "archive"
+const HistoryUnarchive string = "unarchive"
+const HistoryTrash string = "trash"
+const HistoryRestore string = "restore"
+const HistoryDelete string = "delete"
+const HistoryRevert string = "revert"
+
+// Actor user and request making the changes recorded in the history
+// The zero value is used by the background jobs
+type Actor struct {
+	UserId    uint64
+	RequestId string
+}
+
+// HistoryEntry one change of an item, Snapshot is the state of the item after it,
+// or before it for a delete
+type HistoryEntry struct {
+	HistoryId uint64                 `json:"history_id"`
+	ItemId    uint64                 `json:"item_id"`
+	Version   int                    `json:"version"`
+	Action    string                 `json:"action"`
+	ActorId   *uint64                `json:"actor_id"`
+	RequestId *string                `json:"request_id"`
+	Changes   map[string]FieldChange `json:"changes"`
+	Snapshot  map[string]any         `json:"snapshot"`
+	CreatedAt string                 `json:"created_at"`
+}
+
+// FieldChange value of a field before and after a change, null when the item did not exist
+type FieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// HistoryFields fields of the item tracked by the history
+func HistoryFields(item Item) map[string]any {
+	return map[string]any{
+		"assignee_id":  item.AssigneeId,
+		"list_id":      item.ListId,
+		"parent_id":    item.ParentId,
+		"title":        item.Title,
+		"description":  item.Description,
+		"status":       item.Status,
+		"priority":     item.Priority,
+		"position":     item.Position,
+		"due_at":       item.DueAt,
+		"start_at":     item.StartAt,
+		"recurrence":   item.Recurrence,
+		"tags":         item.Tags,
+		"completed_at": item.CompletedAt,
+		"archived_at":  item.ArchivedAt,
+		"deleted_at":   item.DeletedAt,
+	}
+}
+
+// DiffHistoryFields get the fields changed between the two states, a nil state means the item did not exist
+// Values are compared by their JSON encoding
+func DiffHistoryFields(before, after map[string]any) map[string]FieldChange {
+	names := []string{}
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, found := before[name]; !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	changes := map[string]FieldChange{}
+	for _, name := range names {
+		beforeValue, afterValue := before[name], after[name]
+		encodedBefore, _ := json.Marshal(beforeValue)
+		encodedAfter, _ := json.Marshal(afterValue)
+		if before != nil && after != nil && string(encodedBefore) == string(encodedAfter) {
+			continue
+		}
+		changes[name] = FieldChange{Before: beforeValue, After: afterValue}
+	}
+	return changes
+}
+
+// Input get the input restoring the state of the snapshot
+func (entry HistoryEntry) Input() (ItemInput, error) {
+	var itemInput ItemInput
+	encoded, encodeErr := json.Marshal(entry.Snapshot)
+	if encodeErr != nil {
+		return itemInput, encodeErr
+	}
+	if decodeErr := json.Unmarshal(encoded, &itemInput); decodeErr != nil {
+		return itemInput, decodeErr
+	}
+	if itemInput.Tags == nil {
+		itemInput.Tags = []string{}
+	}
+	return itemInput, nil
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffHistoryFields(t *testing.T) {
+	dueAt, otherDueAt := "2026-10-20 09:00:00", "2026-10-21 09:00:00"
+	var noDueAt *string
+	tests := []struct {
+		name   string
+		before map[string]any
+		after  map[string]any
+		want   map[string]FieldChange
+	}{
+		{
+			name:   "no change",
+			before: map[string]any{"title": "Report", "status": StatusProcessing},
+			after:  map[string]any{"title": "Report", "status": StatusProcessing},
+			want:   map[string]FieldChange{},
+		},
+		{
+			name:   "changed field",
+			before: map[string]any{"title": "Report", "status": StatusProcessing},
+			after:  map[string]any{"title": "Report", "status": StatusCompleted},
+			want:   map[string]FieldChange{"status": {Before: StatusProcessing, After: StatusCompleted}},
+		},
+		{
+			name:   "pointers compared by value",
+			before: map[string]any{"due_at": &dueAt},
+			after:  map[string]any{"due_at": &[]string{dueAt}[0]},
+			want:   map[string]FieldChange{},
+		},
+		{
+			name:   "cleared pointer",
+			before: map[string]any{"due_at": &dueAt},
+			after:  map[string]any{"due_at": noDueAt},
+			want:   map[string]FieldChange{"due_at": {Before: &dueAt, After: noDueAt}},
+		},
+		{
+			name:   "changed pointer",
+			before: map[string]any{"due_at": &dueAt},
+			after:  map[string]any{"due_at": &otherDueAt},
+			want:   map[string]FieldChange{"due_at": {Before: &dueAt, After: &otherDueAt}},
+		},
+		{
+			name:   "priority compared by name",
+			before: map[string]any{"priority": PriorityLow},
+			after:  map[string]any{"priority": PriorityHigh},
+			want:   map[string]FieldChange{"priority": {Before: PriorityLow, After: PriorityHigh}},
+		},
+		{
+			name:   "tags in the same order",
+			before: map[string]any{"tags": []string{"home", "work"}},
+			after:  map[string]any{"tags": []string{"home", "work"}},
+			want:   map[string]FieldChange{},
+		},
+		{
+			name:   "field only in one state",
+			before: map[string]any{"title": "Report"},
+			after:  map[string]any{"title": "Report", "archived_at": &dueAt},
+			want:   map[string]FieldChange{"archived_at": {Before: nil, After: &dueAt}},
+		},
+		{
+			name:   "created item",
+			before: nil,
+			after:  map[string]any{"title": "Report", "due_at": noDueAt},
+			want: map[string]FieldChange{
+				"title":  {Before: nil, After: "Report"},
+				"due_at": {Before: nil, After: noDueAt},
+			},
+		},
+		{
+			name:   "deleted item",
+			before: map[string]any{"title": "Report"},
+			after:  nil,
+			want:   map[string]FieldChange{"title": {Before: "Report", After: nil}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := DiffHistoryFields(test.before, test.after)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("DiffHistoryFields = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiffHistoryFieldsOfItems(t *testing.T) {
+	dueAt := "2026-10-20 09:00:00"
+	before := Item{ItemId: 1, Title: "Report", Status: StatusProcessing, Position: "V000000000", Tags: []string{"work"}}
+	after := before
+	after.Title = "Send the report"
+	after.DueAt = &dueAt
+	after.UpdatedAt = "2026-10-18T10:00:00Z"
+
+	got := DiffHistoryFields(HistoryFields(before), HistoryFields(after))
+	want := map[string]FieldChange{
+		"title":  {Before: "Report", After: "Send the report"},
+		"due_at": {Before: (*string)(nil), After: &dueAt},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiffHistoryFields = %#v, want %#v, the update time is not tracked", got, want)
+	}
+}
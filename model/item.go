@@ -1,17 +1,185 @@
 package model
 
+import (
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/recurrence"
+	"time"
+)
+
+// StatusProcessing status of the items to do
+const StatusProcessing int = 1
+
+// StatusCompleted status of the completed items
+const StatusCompleted int = 2
+
+// StatusNames names accepted for the statuses in the filters
+var StatusNames = map[string]int{
+	"todo":       StatusProcessing,
+	"open":       StatusProcessing,
+	"processing": StatusProcessing,
+	"done":       StatusCompleted,
+	"completed":  StatusCompleted,
+}
+
+// DatabaseTimeLayout layout of the datetime columns, always stored in UTC
+const DatabaseTimeLayout string = "2006-01-02 15:04:05"
+
+// inputTimeLayouts layouts accepted for due_at and start_at
+// Layouts without offset are read in the timezone of the user
+var inputTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
 type Item struct {
-	ItemId      uint64 `json:"item_id"`
-	UserId      uint64 `json:"user_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Status      int    `json:"status"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ItemId      uint64    `json:"item_id"`
+	UserId      uint64    `json:"user_id"`
+	AssigneeId  *uint64   `json:"assignee_id"`
+	ListId      *uint64   `json:"list_id"`
+	ParentId    *uint64   `json:"parent_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Status      int       `json:"status"`
+	Priority    Priority  `json:"priority"`
+	Position    string    `json:"position"`
+	Version     int       `json:"version"`
+	DueAt       *string   `json:"due_at"`
+	StartAt     *string   `json:"start_at"`
+	Recurrence  *string   `json:"recurrence"`
+	CompletedAt *string   `json:"completed_at"`
+	ArchivedAt  *string   `json:"archived_at,omitempty"`
+	Tags        []string  `json:"tags"`
+	CreatedAt   string    `json:"created_at"`
+	UpdatedAt   string    `json:"updated_at"`
+	DeletedAt   *string   `json:"deleted_at,omitempty"`
+	Progress    *Progress `json:"progress,omitempty"`
+	Children    []Item    `json:"children,omitempty"`
 }
 
 type ItemInput struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Status      int    `json:"status"`
+	AssigneeId  *uint64  `json:"assignee_id"`
+	ListId      *uint64  `json:"list_id"`
+	ParentId    *uint64  `json:"parent_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Status      int      `json:"status"`
+	Priority    Priority `json:"priority"`
+	DueAt       *string  `json:"due_at"`
+	StartAt     *string  `json:"start_at"`
+	Recurrence  *string  `json:"recurrence"`
+	Tags        []string `json:"tags"`
+}
+
+// ItemPage envelope of the lists of items, with the total count of the matching items
+// Page is set by the offset pagination, the cursors by the keyset pagination
+type ItemPage struct {
+	Items      []Item `json:"items"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Size       int    `json:"size"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// MoveInput target of a move, the item is placed right before or right after it
+type MoveInput struct {
+	Before *uint64 `json:"before"`
+	After  *uint64 `json:"after"`
+}
+
+// Input get the input representing the current state of the item
+// Tags are left nil so they are kept unless changed
+func (item Item) Input() ItemInput {
+	return ItemInput{
+		AssigneeId:  item.AssigneeId,
+		ListId:      item.ListId,
+		ParentId:    item.ParentId,
+		Title:       item.Title,
+		Description: item.Description,
+		Status:      item.Status,
+		Priority:    item.Priority,
+		DueAt:       item.DueAt,
+		StartAt:     item.StartAt,
+		Recurrence:  item.Recurrence,
+	}
+}
+
+// ValidateDates check due_at and start_at of the input
+// and convert them to the UTC database layout
+// An empty string clears the date
+func (itemInput *ItemInput) ValidateDates(loc *time.Location) error {
+	dueAt, dueErr := ParseInputTime(itemInput.DueAt, loc)
+	if dueErr != nil {
+		return fmt.Errorf("invalid due_at, %s", dueErr.Error())
+	}
+	startAt, startErr := ParseInputTime(itemInput.StartAt, loc)
+	if startErr != nil {
+		return fmt.Errorf("invalid start_at, %s", startErr.Error())
+	}
+	if dueAt != nil && startAt != nil && startAt.After(*dueAt) {
+		return fmt.Errorf("start_at must be before due_at")
+	}
+	itemInput.DueAt = formatDatabaseTime(dueAt)
+	itemInput.StartAt = formatDatabaseTime(startAt)
+	return nil
+}
+
+// ValidateTags normalize the tags of the input
+func (itemInput *ItemInput) ValidateTags() error {
+	tags, tagsErr := NormalizeTags(itemInput.Tags)
+	if tagsErr != nil {
+		return tagsErr
+	}
+	itemInput.Tags = tags
+	return nil
+}
+
+// ValidateRecurrence check the RRULE of the input and pin its DTSTART
+// to the due date, the start date or the current time of the user
+// Must be called after ValidateDates, an empty string clears the recurrence
+func (itemInput *ItemInput) ValidateRecurrence(loc *time.Location) error {
+	if itemInput.Recurrence == nil || *itemInput.Recurrence == "" {
+		itemInput.Recurrence = nil
+		return nil
+	}
+	dtstart := time.Now().In(loc).Truncate(time.Minute)
+	for _, anchor := range []*string{itemInput.DueAt, itemInput.StartAt} {
+		if anchor == nil {
+			continue
+		}
+		if parsed, parseErr := time.ParseInLocation(DatabaseTimeLayout, *anchor, time.UTC); parseErr == nil {
+			dtstart = parsed.In(loc)
+			break
+		}
+	}
+	rule, ruleErr := recurrence.Normalize(*itemInput.Recurrence, dtstart)
+	if ruleErr != nil {
+		return ruleErr
+	}
+	itemInput.Recurrence = &rule
+	return nil
+}
+
+// ParseInputTime parse the input value with the accepted layouts
+func ParseInputTime(value *string, loc *time.Location) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+	for _, layout := range inputTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, *value, loc); err == nil {
+			return &parsed, nil
+		}
+	}
+	return nil, fmt.Errorf("%q is not a valid date", *value)
+}
+
+// formatDatabaseTime format the time to the UTC database layout
+func formatDatabaseTime(value *time.Time) *string {
+	if value == nil {
+		return nil
+	}
+	formatted := value.UTC().Format(DatabaseTimeLayout)
+	return &formatted
 }
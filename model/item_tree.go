@@ -0,0 +1,45 @@
+package model
+
+// Progress completion of the descendants of an item
+type Progress struct {
+	Completed int     `json:"completed"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+}
+
+// BuildTree nest the descendants under the root item
+// and compute the progress of every item having children
+func BuildTree(root *Item, descendants []Item) {
+	children := map[uint64][]Item{}
+	for _, descendant := range descendants {
+		if descendant.ParentId != nil {
+			children[*descendant.ParentId] = append(children[*descendant.ParentId], descendant)
+		}
+	}
+	attachChildren(root, children)
+}
+
+// attachChildren recursively attach the children of the item
+// Return the number of completed and total descendants of the item
+func attachChildren(item *Item, children map[uint64][]Item) (int, int) {
+	item.Children = children[item.ItemId]
+	completed, total := 0, 0
+	for i := range item.Children {
+		child := &item.Children[i]
+		childCompleted, childTotal := attachChildren(child, children)
+		completed += childCompleted
+		total += childTotal + 1
+		if child.Status == StatusCompleted {
+			completed++
+		}
+	}
+	item.Progress = nil
+	if total > 0 {
+		item.Progress = &Progress{
+			Completed: completed,
+			Total:     total,
+			Percent:   float64(completed) * 100 / float64(total),
+		}
+	}
+	return completed, total
+}
@@ -0,0 +1,13 @@
+package model
+
+type List struct {
+	ListId    uint64 `json:"list_id"`
+	UserId    uint64 `json:"user_id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ListInput struct {
+	Name string `json:"name"`
+}
@@ -0,0 +1,14 @@
+package model
+
+// LoginSession device the user is logged in from, it follows one family of refresh tokens
+// Current is set for the session of the request
+type LoginSession struct {
+	LoginSessionId uint64 `json:"session_id"`
+	UserId         uint64 `json:"user_id"`
+	FamilyId       string `json:"-"`
+	UserAgent      string `json:"user_agent"`
+	IpAddress      string `json:"ip_address"`
+	CreatedAt      string `json:"created_at"`
+	LastSeenAt     string `json:"last_seen_at"`
+	Current        bool   `json:"current"`
+}
@@ -0,0 +1,20 @@
+package model
+
+// NotificationAssigned sent to the user an item is assigned to
+const NotificationAssigned string = "item.assigned"
+
+// NotificationUnassigned sent to the user an item is not assigned to anymore
+const NotificationUnassigned string = "item.unassigned"
+
+// Notification event sent to a user, Payload depends on the type
+// ActorId is the user who made the change, empty for the background jobs
+type Notification struct {
+	NotificationId uint64         `json:"notification_id"`
+	UserId         uint64         `json:"user_id"`
+	ItemId         *uint64        `json:"item_id"`
+	ActorId        *uint64        `json:"actor_id"`
+	Type           string         `json:"type"`
+	Payload        map[string]any `json:"payload"`
+	ReadAt         *string        `json:"read_at"`
+	CreatedAt      string         `json:"created_at"`
+}
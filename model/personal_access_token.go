@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ScopeItemsRead scope of the personal access tokens reading the items
+const ScopeItemsRead string = "items:read"
+
+// ScopeItemsWrite scope of the personal access tokens changing the items
+const ScopeItemsWrite string = "items:write"
+
+// MaxPersonalAccessTokenNameLength size of the name column of the personal access tokens
+const MaxPersonalAccessTokenNameLength int = 255
+
+// PersonalAccessTokenScopes scopes a personal access token can be granted
+var PersonalAccessTokenScopes = map[string]bool{
+	ScopeItemsRead:  true,
+	ScopeItemsWrite: true,
+}
+
+// PersonalAccessToken named long-lived token of a user, stored by its hash
+// Token is the secret, only set in the response of the creation
+type PersonalAccessToken struct {
+	PersonalAccessTokenId uint64   `json:"token_id"`
+	UserId                uint64   `json:"user_id"`
+	Name                  string   `json:"name"`
+	Token                 string   `json:"token,omitempty"`
+	TokenHash             string   `json:"-"`
+	Scopes                []string `json:"scopes"`
+	ExpiresAt             *string  `json:"expires_at"`
+	LastUsedAt            *string  `json:"last_used_at"`
+	CreatedAt             string   `json:"created_at"`
+}
+
+// PersonalAccessTokenInput name, scopes and optional expiry of a new personal access token
+type PersonalAccessTokenInput struct {
+	Name      string   `json:"name" form:"name"`
+	Scopes    []string `json:"scopes" form:"scopes"`
+	ExpiresAt *string  `json:"expires_at" form:"expires_at"`
+}
+
+// HasScope check the token was granted the scope
+func (token PersonalAccessToken) HasScope(scope string) bool {
+	for _, granted := range token.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired check the token is expired at the time, the tokens without expiry never expire
+func (token PersonalAccessToken) Expired(now time.Time) bool {
+	if token.ExpiresAt == nil {
+		return false
+	}
+	expiresAt, parseErr := time.Parse(time.RFC3339, *token.ExpiresAt)
+	return parseErr != nil || !now.Before(expiresAt)
+}
+
+// Validate check the name, the scopes and the expiry of the input
+// The scopes are deduplicated and sorted, the expiry is converted to the UTC database layout
+func (input *PersonalAccessTokenInput) Validate(loc *time.Location, now time.Time) error {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len([]rune(input.Name)) > MaxPersonalAccessTokenNameLength {
+		return fmt.Errorf("the name is required, at most %d characters", MaxPersonalAccessTokenNameLength)
+	}
+	scopes := map[string]bool{}
+	for _, scope := range input.Scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !PersonalAccessTokenScopes[scope] {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+		scopes[scope] = true
+	}
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	input.Scopes = input.Scopes[:0]
+	for scope := range scopes {
+		input.Scopes = append(input.Scopes, scope)
+	}
+	sort.Strings(input.Scopes)
+	expiresAt, expiresErr := ParseInputTime(input.ExpiresAt, loc)
+	if expiresErr != nil {
+		return fmt.Errorf("invalid expires_at, %s", expiresErr.Error())
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return fmt.Errorf("expires_at must be in the future")
+	}
+	input.ExpiresAt = formatDatabaseTime(expiresAt)
+	return nil
+}
@@ -0,0 +1,60 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Priority of an item, stored as a number and exposed by name in JSON
+type Priority int
+
+const (
+	PriorityNone Priority = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = []string{"none", "low", "medium", "high", "urgent"}
+
+// String get the name of the priority
+func (priority Priority) String() string {
+	if priority < PriorityNone || priority > PriorityUrgent {
+		return fmt.Sprintf("Priority(%d)", int(priority))
+	}
+	return priorityNames[priority]
+}
+
+// ParsePriority get the priority from its name
+func ParsePriority(name string) (Priority, error) {
+	for i, priorityName := range priorityNames {
+		if priorityName == name {
+			return Priority(i), nil
+		}
+	}
+	return PriorityNone, fmt.Errorf("invalid priority %q", name)
+}
+
+// MarshalJSON write the priority name
+func (priority Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(priority.String())
+}
+
+// UnmarshalJSON read the priority name, an empty name means none
+func (priority *Priority) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("priority must be one of none, low, medium, high, urgent")
+	}
+	if name == "" {
+		*priority = PriorityNone
+		return nil
+	}
+	parsed, parseErr := ParsePriority(name)
+	if parseErr != nil {
+		return parseErr
+	}
+	*priority = parsed
+	return nil
+}
@@ -0,0 +1,13 @@
+package model
+
+type SearchResult struct {
+	Item
+	Score     float64   `json:"score"`
+	Highlight Highlight `json:"highlight"`
+}
+
+// Highlight HTML escaped title and description snippet with the matches wrapped in <mark>
+type Highlight struct {
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+}
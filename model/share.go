@@ -0,0 +1,65 @@
+package model
+
+// RoleViewer can read the shared items and comment on them
+const RoleViewer string = "viewer"
+
+// RoleEditor can also change the shared items and add items to the shared lists
+const RoleEditor string = "editor"
+
+// RoleOwner can also delete the shared items and share them with other users
+const RoleOwner string = "owner"
+
+// roleRanks order of the roles, each role can do everything the lower ones can
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Share grant of a role on an item or on a list, with all its items, to another user
+// The grant gives access once the invited user has accepted it
+// Name is the title of the item or the name of the list
+type Share struct {
+	ShareId         uint64  `json:"share_id"`
+	ItemId          *uint64 `json:"item_id"`
+	ListId          *uint64 `json:"list_id"`
+	Name            string  `json:"name"`
+	OwnerId         uint64  `json:"owner_id"`
+	OwnerUsername   string  `json:"owner_username"`
+	GranteeId       uint64  `json:"grantee_id"`
+	GranteeUsername string  `json:"grantee_username"`
+	Role            string  `json:"role"`
+	InvitedBy       uint64  `json:"invited_by"`
+	AcceptedAt      *string `json:"accepted_at"`
+	CreatedAt       string  `json:"created_at"`
+	UpdatedAt       string  `json:"updated_at"`
+}
+
+// ShareInput invitation of a user, by username or email, to an item or a list
+type ShareInput struct {
+	ItemId  *uint64 `json:"item_id"`
+	ListId  *uint64 `json:"list_id"`
+	Invitee string  `json:"invitee"`
+	Role    string  `json:"role"`
+}
+
+// ValidRole check the role is one of the share roles
+func ValidRole(role string) bool {
+	return roleRanks[role] > 0
+}
+
+// RoleAllows check the role gives at least the rights of the required role
+func RoleAllows(role, required string) bool {
+	return ValidRole(role) && roleRanks[role] >= roleRanks[required]
+}
+
+// HighestRole get the role giving the most rights, empty without roles
+func HighestRole(roles ...string) string {
+	highest := ""
+	for _, role := range roles {
+		if roleRanks[role] > roleRanks[highest] {
+			highest = role
+		}
+	}
+	return highest
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// tagNameRegex allowed characters of a tag name, after the leading # is removed
+var tagNameRegex = regexp.MustCompile(`^[\p{L}\p{N}_-]{1,64}$`)
+
+type Tag struct {
+	TagId      uint64 `json:"tag_id"`
+	UserId     uint64 `json:"user_id"`
+	Name       string `json:"name"`
+	UsageCount int    `json:"usage_count"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+type TagInput struct {
+	Name string `json:"name"`
+}
+
+// NormalizeTag trim the leading # and lower case the tag name
+// Return error when the name contains not allowed characters
+func NormalizeTag(name string) (string, error) {
+	normalized := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(name), "#"))
+	if !tagNameRegex.MatchString(normalized) {
+		return "", fmt.Errorf("invalid tag %q", name)
+	}
+	return normalized, nil
+}
+
+// NormalizeTags normalize and remove the duplicated tag names
+func NormalizeTags(names []string) ([]string, error) {
+	if names == nil {
+		return nil, nil
+	}
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		tag, tagErr := NormalizeTag(name)
+		if tagErr != nil {
+			return nil, tagErr
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+// TokenPair tokens given to the API clients, send the access token in the Authorization: Bearer header
+// ExpiresIn is the lifetime of the access token in seconds
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// Credentials username and password exchanged for a token pair
+type Credentials struct {
+	Username string `json:"username" form:"username"`
+	Password string `json:"password" form:"password"`
+}
+
+// RefreshToken refresh token stored by its hash, it is used once then replaced by a successor of the same family
+type RefreshToken struct {
+	RefreshTokenId uint64  `json:"refresh_token_id"`
+	UserId         uint64  `json:"user_id"`
+	FamilyId       string  `json:"family_id"`
+	TokenHash      string  `json:"-"`
+	ExpiresAt      string  `json:"expires_at"`
+	UsedAt         *string `json:"used_at"`
+	RevokedAt      *string `json:"revoked_at"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+// RefreshInput refresh token exchanged for a new token pair
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
+}
+
+// Expired check the refresh token is expired at the time
+func (token RefreshToken) Expired(now time.Time) bool {
+	expiresAt, parseErr := time.Parse(time.RFC3339, token.ExpiresAt)
+	return parseErr != nil || !now.Before(expiresAt)
+}
@@ -1,10 +1,13 @@
 package model
 
 type User struct {
-	UserId    uint64 `json:"user_id"`
-	Username  string `json:"username"`
-	Email     string `json:"email"`
-	Password  string `json:"password"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
+	UserId   uint64 `json:"user_id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Timezone string `json:"timezone"`
+	// TokenVersion the access tokens of an older version are refused
+	TokenVersion int    `json:"token_version"`
+	CreatedAt    string `json:"created_at"`
+	UpdatedAt    string `json:"updated_at"`
 }
@@ -1,17 +1,36 @@
 // Selectors
 const usernameInput = document.getElementById("username")
 const toDoInput = document.querySelector('.todo-input');
+const dueInput = document.querySelector('.due-input');
 const toDoBtn = document.querySelector('.todo-btn');
 const toDoList = document.querySelector('.todo-list');
 const standardTheme = document.querySelector('.standard-theme');
 const lightTheme = document.querySelector('.light-theme');
 const darkerTheme = document.querySelector('.darker-theme');
+const listSelect = document.querySelector('.list-select');
+const listBtn = document.querySelector('.list-btn');
+const viewSelect = document.querySelector('.view-select');
 
 const itemUrl = `${window.location.origin}/items`;
+const listUrl = `${window.location.origin}/lists`;
 const logOutUrl = `${window.location.origin}/logout`;
+const timezoneUrl = `${window.location.origin}/account/timezone`;
+const shareUrl = `${window.location.origin}/shares`;
 const STATUS_PROCESSING = 1;
 const STATUS_COMPLETED = 2;
 
+// Currently selected list, empty means all tasks
+let currentList = localStorage.getItem('currentList') || '';
+// Current due date view: today, upcoming, overdue, empty means all
+let currentView = '';
+// Views answering with a bare list of items instead of a page
+const dueViews = ['today', 'upcoming', 'overdue'];
+// Timezone of the user, due dates are displayed in it
+let userTimezone = document.body.getAttribute('data-timezone') || 'UTC';
+// Item being dragged and the item it was in front of when the drag started
+let draggedItem = null;
+let draggedItemNext = null;
+
 
 // Event Listeners
 
@@ -20,6 +39,14 @@ usernameInput.addEventListener('mouseout', usernameMouseOut);
 toDoBtn.addEventListener('click', addToDo);
 toDoList.addEventListener('click', todoAction);
 document.addEventListener("DOMContentLoaded", getTodos);
+document.addEventListener("DOMContentLoaded", getLists);
+listSelect.addEventListener('change', switchList);
+listBtn.addEventListener('click', addList);
+viewSelect.addEventListener('change', switchView);
+toDoList.addEventListener('dragstart', dragStart);
+toDoList.addEventListener('dragover', dragOver);
+toDoList.addEventListener('dragend', dragEnd);
+document.addEventListener("DOMContentLoaded", syncTimezone);
 standardTheme.addEventListener('click', () => changeTheme('standard'));
 lightTheme.addEventListener('click', () => changeTheme('light'));
 darkerTheme.addEventListener('click', () => changeTheme('darker'));
@@ -61,18 +88,67 @@ function todoAction(event){
     {
         completeItem(item, completeItemElement)
     }
+
+    // restore from the trash
+    if(item.getAttribute("data-action") === 'restore')
+    {
+        restoreItem(item, removeItemElement)
+    }
+
+    // back from the archive
+    if(item.getAttribute("data-action") === 'unarchive')
+    {
+        unarchiveItem(item, removeItemElement)
+    }
+
+    // show or hide the comments
+    if(item.getAttribute("data-action") === 'comments')
+    {
+        toggleComments(item)
+    }
+
+    // post a comment
+    if(item.getAttribute("data-action") === 'comment')
+    {
+        addComment(item)
+    }
+
+    // accept or decline an invitation to a shared item or list
+    if(item.getAttribute("data-action") === 'accept-share')
+    {
+        answerInvitation(item, true)
+    }
+    if(item.getAttribute("data-action") === 'decline-share')
+    {
+        answerInvitation(item, false)
+    }
 }
 
 // Saving to local storage:
-function saveItem(todo, callback){
+// Split the #tags out of the typed task
+function parseTags(todo) {
+    const tags = todo.match(/#[\p{L}\p{N}_-]+/gu) || [];
+    const title = todo.replace(/#[\p{L}\p{N}_-]+/gu, '').replace(/\s+/g, ' ').trim();
+    return {
+        title: title || todo,
+        tags: tags.map(tag => tag.substring(1).toLowerCase())
+    };
+}
+
+function saveItem(todo, dueAt, callback){
+    const parsed = parseTags(todo);
     fetch(itemUrl, {
         method: 'POST',
         headers: {
             'Content-Type': 'application/json',
         },
         body: JSON.stringify({
-            title: todo,
-            status: 1
+            title: parsed.title,
+            tags: parsed.tags,
+            status: 1,
+            list_id: currentList ? Number(currentList) : null,
+            // datetime-local value, read by the server in the timezone of the user
+            due_at: dueAt || null
         }) // Convert data to JSON string
     })
         .then(response => response.json())
@@ -86,7 +162,17 @@ function saveItem(todo, callback){
 
 function getTodos() {
     toDoList.innerHTML = "";
-    fetch(itemUrl)
+    if (currentView === 'shared') {
+        getInvitations();
+    }
+    let url = currentView ? `${itemUrl}/${currentView}` : itemUrl;
+    if (!currentView && currentList) {
+        url = `${itemUrl}?list=${currentList}`;
+    }
+    if (currentView === 'assigned') {
+        url = `${itemUrl}?assignee=me`;
+    }
+    fetch(url)
         .then(response => {
             // Check if the response status is OK (status code 200)
             if (!response.ok) {
@@ -97,9 +183,11 @@ function getTodos() {
             return response.json();
         })
         .then(data => {
-            // Handle the data from the response
-            for (var key in data) {
-                addToDoElement(data[key])
+            // Handle the data from the response, the due date views answer with the items,
+            // the other views with a page of items
+            const items = dueViews.includes(currentView) ? data : data.items;
+            for (var key in items) {
+                addToDoElement(items[key])
             }
         })
         .catch(error => {
@@ -117,15 +205,19 @@ function addToDo(event) {
     }
 
     // Adding to local storage;
-    saveItem(toDoInput.value, getTodos);
+    saveItem(toDoInput.value, dueInput.value, getTodos);
     // Clear the input;
     toDoInput.value = '';
+    dueInput.value = '';
 }
 
 function addToDoElement(item) {
     // toDo DIV;
     const toDoDiv = document.createElement("div");
     toDoDiv.classList.add('todo', `${savedTheme}-todo`);
+    toDoDiv.setAttribute("data-item-id", item.item_id);
+    // The due date views are sorted by due date, only the lists can be reordered
+    toDoDiv.draggable = !currentView;
     if (item.status === STATUS_COMPLETED) {
         toDoDiv.classList.add('completed')
     }
@@ -139,13 +231,62 @@ function addToDoElement(item) {
     newToDo.setAttribute("id", `todo-item-${item.item_id}`);
     toDoDiv.appendChild(newToDo);
 
-    // check btn;
+    // priority
+    if (item.priority && item.priority !== 'none') {
+        const priority = document.createElement('span');
+        priority.innerText = item.priority;
+        priority.classList.add('todo-priority', `priority-${item.priority}`);
+        toDoDiv.appendChild(priority);
+    }
+
+    // tags
+    (item.tags || []).forEach(tag => {
+        const chip = document.createElement('span');
+        chip.innerText = `#${tag}`;
+        chip.classList.add('todo-tag');
+        toDoDiv.appendChild(chip);
+    });
+
+    // due date, displayed in the timezone of the user
+    if (item.due_at) {
+        const due = document.createElement('span');
+        const dueDate = new Date(item.due_at);
+        due.innerText = dueDate.toLocaleString(undefined, {
+            timeZone: userTimezone,
+            dateStyle: 'medium',
+            timeStyle: 'short'
+        });
+        due.classList.add('todo-due');
+        if (dueDate < new Date() && item.status !== STATUS_COMPLETED) {
+            due.classList.add('overdue');
+        }
+        toDoDiv.appendChild(due);
+    }
+
+    // check btn, restore btn in the trash, unarchive btn in the archive;
     const checked = document.createElement('button');
     checked.innerHTML = '<i class="fas fa-check"></i>';
     checked.classList.add('check-btn', `${savedTheme}-button`);
     checked.setAttribute("data-item-id", item.item_id);
     checked.setAttribute("data-action", "checked");
+    if (currentView === 'trash') {
+        checked.innerHTML = '<i class="fas fa-undo"></i>';
+        checked.setAttribute("data-action", "restore");
+    }
+    if (currentView === 'archive') {
+        checked.innerHTML = '<i class="fas fa-box-open"></i>';
+        checked.setAttribute("data-action", "unarchive");
+    }
     toDoDiv.appendChild(checked);
+    // comments btn, the trashed items can't be commented
+    if (currentView !== 'trash') {
+        const comments = document.createElement('button');
+        comments.innerHTML = '<i class="fas fa-comment"></i>';
+        comments.classList.add('comment-btn', `${savedTheme}-button`);
+        comments.setAttribute("data-item-id", item.item_id);
+        comments.setAttribute("data-action", "comments");
+        toDoDiv.appendChild(comments);
+    }
     // delete btn;
     const deleted = document.createElement('button');
     deleted.innerHTML = '<i class="fas fa-trash"></i>';
@@ -158,19 +299,80 @@ function addToDoElement(item) {
     toDoList.appendChild(toDoDiv);
 }
 
+// Invitations waiting for an answer, shown on top of the shared items
+function getInvitations() {
+    fetch(`${shareUrl}/invitations`)
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+            return response.json();
+        })
+        .then(invitations => {
+            invitations.forEach(addInvitationElement);
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
+function addInvitationElement(share) {
+    const invitationDiv = document.createElement("div");
+    invitationDiv.classList.add('todo', 'invitation', `${savedTheme}-todo`);
+
+    const text = document.createElement('li');
+    const target = share.list_id ? 'list' : 'task';
+    text.innerText = `${share.owner_username} shares the ${target} "${share.name}" with you as ${share.role}`;
+    text.classList.add('todo-item');
+    invitationDiv.appendChild(text);
+
+    const accept = document.createElement('button');
+    accept.innerHTML = '<i class="fas fa-check"></i>';
+    accept.classList.add('check-btn', `${savedTheme}-button`);
+    accept.setAttribute("data-share-id", share.share_id);
+    accept.setAttribute("data-action", "accept-share");
+    invitationDiv.appendChild(accept);
+
+    const decline = document.createElement('button');
+    decline.innerHTML = '<i class="fas fa-times"></i>';
+    decline.classList.add('delete-btn', `${savedTheme}-button`);
+    decline.setAttribute("data-share-id", share.share_id);
+    decline.setAttribute("data-action", "decline-share");
+    invitationDiv.appendChild(decline);
+
+    toDoList.insertBefore(invitationDiv, toDoList.firstChild);
+}
+
+function answerInvitation(itemElement, accepted) {
+    const shareId = itemElement.getAttribute("data-share-id");
+    fetch(accepted ? `${shareUrl}/${shareId}/accept` : `${shareUrl}/${shareId}`, {
+        method: accepted ? 'POST' : 'DELETE'
+    })
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+            // The accepted items and lists show up right away
+            getTodos();
+            getLists();
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
 function completeItem(itemElement, callback){
     let itemId = itemElement.getAttribute("data-item-id"),
-        currentTitle = document.getElementById(`todo-item-${itemId}`),
-        completed = itemElement.parentElement.classList.contains("completed")
+        completed = itemElement.parentElement.classList.contains("completed"),
         itemUrlEncoded = itemUrl + "/" + itemId;
+    // Merge patch, only the status is changed
     fetch(itemUrlEncoded, {
-        method: 'PUT',
+        method: 'PATCH',
         headers: {
-            'Content-Type': 'application/json',
+            'Content-Type': 'application/merge-patch+json',
         },
         body: JSON.stringify({
-            title: currentTitle.innerText,
-            status: !!completed ? 1 : 2
+            status: !!completed ? STATUS_PROCESSING : STATUS_COMPLETED
         }) // Convert data to JSON string
     })
         .then(response => response.json())
@@ -190,6 +392,10 @@ function completeItemElement(itemElement) {
 function removeItem(itemElement, callback){
     let itemId = itemElement.getAttribute("data-item-id"),
         itemUrlEncoded = itemUrl + "/" + itemId;
+    // Items are moved to the trash, deleting from the trash is for good
+    if (currentView === 'trash') {
+        itemUrlEncoded += "?permanent=true";
+    }
     fetch(itemUrlEncoded, {
         method: 'DELETE'
     })
@@ -202,8 +408,37 @@ function removeItem(itemElement, callback){
         });
 }
 
+function restoreItem(itemElement, callback){
+    let itemId = itemElement.getAttribute("data-item-id");
+    fetch(`${itemUrl}/${itemId}/restore`, {
+        method: 'POST'
+    })
+        .then(response => response.json())
+        .then(data => {
+            callback(itemElement)
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
+function unarchiveItem(itemElement, callback){
+    let itemId = itemElement.getAttribute("data-item-id");
+    fetch(`${itemUrl}/${itemId}/unarchive`, {
+        method: 'POST'
+    })
+        .then(response => response.json())
+        .then(data => {
+            callback(itemElement)
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
 function removeItemElement(itemElement) {
     // item.parentElement.remove();
+    closeComments(itemElement.parentElement);
     // animation
     itemElement.parentElement.classList.add("fall");
 
@@ -212,6 +447,290 @@ function removeItemElement(itemElement) {
     });
 }
 
+// Comments panel shown right after the item, empty when it's closed
+function findComments(toDoDiv) {
+    const panel = toDoDiv.nextElementSibling;
+    return panel && panel.classList.contains('comments') ? panel : null;
+}
+
+function closeComments(toDoDiv) {
+    const panel = findComments(toDoDiv);
+    if (panel) {
+        panel.remove();
+    }
+}
+
+function toggleComments(itemElement) {
+    const toDoDiv = itemElement.parentElement;
+    if (findComments(toDoDiv)) {
+        closeComments(toDoDiv);
+        return
+    }
+    const itemId = itemElement.getAttribute("data-item-id");
+    const panel = document.createElement('div');
+    panel.classList.add('comments', `${savedTheme}-todo`);
+    panel.setAttribute("data-item-id", itemId);
+
+    const thread = document.createElement('div');
+    thread.classList.add('comments-thread');
+    panel.appendChild(thread);
+
+    const input = document.createElement('textarea');
+    input.classList.add('comment-input');
+    input.placeholder = 'Write a comment, Markdown is supported';
+    panel.appendChild(input);
+
+    const post = document.createElement('button');
+    post.innerText = 'Comment';
+    post.classList.add('comment-post-btn', `${savedTheme}-button`);
+    post.setAttribute("data-item-id", itemId);
+    post.setAttribute("data-action", "comment");
+    panel.appendChild(post);
+
+    toDoList.insertBefore(panel, toDoDiv.nextElementSibling);
+    getComments(panel);
+}
+
+function getComments(panel) {
+    const itemId = panel.getAttribute("data-item-id");
+    fetch(`${itemUrl}/${itemId}/comments/`)
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+            return response.json();
+        })
+        .then(comments => {
+            const thread = panel.querySelector('.comments-thread');
+            thread.innerHTML = "";
+            comments.forEach(comment => addCommentElement(thread, comment));
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
+function addCommentElement(thread, comment) {
+    const commentDiv = document.createElement('div');
+    commentDiv.classList.add('comment');
+
+    const author = document.createElement('span');
+    author.classList.add('comment-author');
+    // created_at is in the UTC database layout
+    const createdAt = new Date(`${comment.created_at.replace(' ', 'T')}Z`);
+    author.innerText = `${comment.username} · ${createdAt.toLocaleString(undefined, {
+        timeZone: userTimezone,
+        dateStyle: 'medium',
+        timeStyle: 'short'
+    })}`;
+    commentDiv.appendChild(author);
+
+    // Rendered and sanitized by the server
+    const body = document.createElement('div');
+    body.classList.add('comment-body');
+    body.innerHTML = comment.html;
+    commentDiv.appendChild(body);
+
+    thread.appendChild(commentDiv);
+}
+
+function addComment(itemElement) {
+    const panel = itemElement.parentElement,
+        input = panel.querySelector('.comment-input'),
+        itemId = itemElement.getAttribute("data-item-id");
+    if (!input.value.trim()) {
+        return
+    }
+    fetch(`${itemUrl}/${itemId}/comments/`, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({
+            body: input.value
+        })
+    })
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+            return response.json();
+        })
+        .then(comment => {
+            input.value = "";
+            addCommentElement(panel.querySelector('.comments-thread'), comment);
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
+function dragStart(event) {
+    draggedItem = event.target.closest('.todo');
+    if (!draggedItem) {
+        return
+    }
+    closeComments(draggedItem);
+    draggedItemNext = draggedItem.nextElementSibling;
+    draggedItem.classList.add('dragging');
+    event.dataTransfer.effectAllowed = 'move';
+}
+
+function dragOver(event) {
+    if (!draggedItem) {
+        return
+    }
+    event.preventDefault();
+    const target = event.target.closest('.todo');
+    if (!target || target === draggedItem) {
+        return
+    }
+    // Drop after the hovered item when the pointer is on its lower half
+    const box = target.getBoundingClientRect();
+    const after = event.clientY > box.top + box.height / 2;
+    // Keep the comments of the hovered item right after it
+    const last = findComments(target) || target;
+    toDoList.insertBefore(draggedItem, after ? last.nextElementSibling : target);
+}
+
+function dragEnd() {
+    if (!draggedItem) {
+        return
+    }
+    const itemElement = draggedItem;
+    itemElement.classList.remove('dragging');
+    draggedItem = null;
+    if (itemElement.nextElementSibling !== draggedItemNext) {
+        moveItem(itemElement);
+    }
+}
+
+function moveItem(itemElement){
+    // Skip the comments panels, only the items have a position
+    let itemId = itemElement.getAttribute("data-item-id"),
+        previous = siblingItem(itemElement, 'previousElementSibling'),
+        next = siblingItem(itemElement, 'nextElementSibling'),
+        target = previous
+            ? {after: Number(previous.getAttribute("data-item-id"))}
+            : {before: Number(next.getAttribute("data-item-id"))};
+    fetch(`${itemUrl}/${itemId}/move`, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify(target)
+    })
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+        })
+        .catch(error => {
+            console.log(error);
+            // Put the item back where the server has it
+            getTodos();
+        });
+}
+
+function siblingItem(itemElement, direction) {
+    let sibling = itemElement[direction];
+    while (sibling && !sibling.classList.contains('todo')) {
+        sibling = sibling[direction];
+    }
+    return sibling;
+}
+
+function getLists() {
+    fetch(listUrl)
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+            return response.json();
+        })
+        .then(data => {
+            listSelect.innerHTML = '<option value="">All tasks</option>';
+            data.forEach(list => {
+                const option = document.createElement('option');
+                option.value = list.list_id;
+                option.innerText = list.name;
+                listSelect.appendChild(option);
+            });
+            // Fall back to all tasks when the saved list does not exist anymore
+            if (!data.some(list => String(list.list_id) === currentList)) {
+                currentList = '';
+                localStorage.setItem('currentList', currentList);
+            }
+            listSelect.value = currentList;
+        })
+        .catch(error => {
+            console.error("Error:", error);
+        });
+}
+
+function switchList() {
+    currentList = listSelect.value;
+    localStorage.setItem('currentList', currentList);
+    getTodos();
+}
+
+function switchView() {
+    currentView = viewSelect.value;
+    getTodos();
+}
+
+// Save the browser timezone for users who never set one
+function syncTimezone() {
+    const browserTimezone = Intl.DateTimeFormat().resolvedOptions().timeZone;
+    if (userTimezone !== 'UTC' || !browserTimezone || browserTimezone === userTimezone) {
+        return
+    }
+    fetch(timezoneUrl, {
+        method: 'PUT',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({
+            timezone: browserTimezone
+        })
+    })
+        .then(response => {
+            if (response.ok) {
+                userTimezone = browserTimezone;
+                getTodos();
+            }
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
+function addList() {
+    const name = prompt("Name of the new list:");
+    if (!name) {
+        return
+    }
+    fetch(listUrl, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/json',
+        },
+        body: JSON.stringify({
+            name: name
+        })
+    })
+        .then(response => response.json())
+        .then(data => {
+            currentList = String(data.list_id);
+            localStorage.setItem('currentList', currentList);
+            getLists();
+            getTodos();
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
 // Change theme function:
 function changeTheme(color) {
     localStorage.setItem('savedTheme', color);
@@ -224,12 +743,16 @@ function changeTheme(color) {
         : document.getElementById('title').classList.remove('darker-title');
 
     document.querySelector('input').className = `${color}-input`;
+    dueInput.className = `due-input ${color}-input`;
     // Change todo color without changing their status (completed or not):
     document.querySelectorAll('.todo').forEach(todo => {
         Array.from(todo.classList).some(item => item === 'completed') ?
             todo.className = `todo ${color}-todo completed`
             : todo.className = `todo ${color}-todo`;
     });
+    document.querySelectorAll('.comments').forEach(panel => {
+        panel.className = `comments ${color}-todo`;
+    });
     // Change buttons color according to their type (todo, check or delete):
     document.querySelectorAll('button').forEach(button => {
         Array.from(button.classList).some(item => {
@@ -239,6 +762,12 @@ function changeTheme(color) {
                 button.className = `delete-btn ${color}-button`;
             } else if (item === 'todo-btn') {
                 button.className = `todo-btn ${color}-button`;
+            } else if (item === 'list-btn') {
+                button.className = `list-btn ${color}-button`;
+            } else if (item === 'comment-btn') {
+                button.className = `comment-btn ${color}-button`;
+            } else if (item === 'comment-post-btn') {
+                button.className = `comment-post-btn ${color}-button`;
             }
         });
     });
@@ -0,0 +1,104 @@
+// Selectors
+const sessionList = document.querySelector('.session-list');
+const logoutAllBtn = document.querySelector('.logout-all-btn');
+
+const sessionUrl = `${window.location.origin}/account/sessions`;
+const logoutAllUrl = `${window.location.origin}/auth/logout-all`;
+const loginUrl = `${window.location.origin}/login`;
+
+// Theme chosen on the dashboard
+const savedTheme = localStorage.getItem('savedTheme') || 'standard';
+
+
+// Event Listeners
+
+document.addEventListener("DOMContentLoaded", getSessions);
+sessionList.addEventListener('click', sessionAction);
+logoutAllBtn.addEventListener('click', logoutAll);
+
+document.body.className = savedTheme;
+logoutAllBtn.classList.add(`${savedTheme}-button`);
+
+// Functions;
+function getSessions() {
+    fetch(sessionUrl)
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+            return response.json();
+        })
+        .then(sessions => {
+            sessionList.innerHTML = '';
+            sessions.forEach(addSessionElement);
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
+function addSessionElement(session) {
+    const sessionDiv = document.createElement("div");
+    sessionDiv.classList.add('todo', `${savedTheme}-todo`);
+
+    const text = document.createElement('li');
+    const device = session.user_agent || 'Unknown device';
+    const current = session.current ? ' (this device)' : '';
+    text.innerText = `${device}${current}\n${session.ip_address} - signed in ${session.created_at}, last seen ${session.last_seen_at}`;
+    text.classList.add('todo-item');
+    sessionDiv.appendChild(text);
+
+    const signOut = document.createElement('button');
+    signOut.innerHTML = '<i class="fas fa-sign-out-alt"></i>';
+    signOut.title = 'Sign out this device';
+    signOut.classList.add('delete-btn', `${savedTheme}-button`);
+    signOut.setAttribute("data-session-id", session.session_id);
+    signOut.setAttribute("data-current", session.current);
+    signOut.setAttribute("data-action", "sign-out");
+    sessionDiv.appendChild(signOut);
+
+    sessionList.appendChild(sessionDiv);
+}
+
+function sessionAction(event) {
+    const item = event.target;
+    if (item.getAttribute("data-action") === 'sign-out') {
+        signOutSession(item);
+    }
+}
+
+function signOutSession(itemElement) {
+    const sessionId = itemElement.getAttribute("data-session-id");
+    fetch(`${sessionUrl}/${sessionId}`, {
+        method: 'DELETE'
+    })
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+            // Signing out this device ends the current session too
+            if (itemElement.getAttribute("data-current") === 'true') {
+                window.location.href = loginUrl;
+                return;
+            }
+            itemElement.parentElement.remove();
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
+
+function logoutAll() {
+    fetch(logoutAllUrl, {
+        method: 'POST'
+    })
+        .then(response => {
+            if (!response.ok) {
+                throw new Error(`HTTP error! Status: ${response.status}`);
+            }
+            window.location.href = loginUrl;
+        })
+        .catch(error => {
+            console.log(error);
+        });
+}
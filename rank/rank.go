@@ -0,0 +1,150 @@
+package rank
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// digits of the rank keys, in byte order so the keys sort with a binary collation
+const digits string = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// HeadLength length of the integer head of a key, the fraction after it is only used
+// to fit a key between two consecutive heads
+const HeadLength int = 10
+
+const InvalidOrderError string = "the first key must sort before the second one"
+const NoRoomError string = "no key sorts between %q and %q"
+
+// First key of an empty sequence
+var First = "V" + strings.Repeat("0", HeadLength-1)
+
+// Between get a key sorting strictly between before and after
+// An empty before means the start of the sequence, an empty after its end
+// Appending or prepending changes the head only, so the keys stay short
+// Nothing sorts before the smallest key, made of zero digits only, so prepending to it fails
+func Between(before, after string) (string, error) {
+	if before != "" && after != "" && before >= after {
+		return "", errors.New(InvalidOrderError)
+	}
+	key := between(before, after)
+	if (before != "" && key <= before) || (after != "" && key >= after) {
+		return "", fmt.Errorf(NoRoomError, before, after)
+	}
+	return key, nil
+}
+
+// between get a key for Between, the keys given by hand may not leave room for it
+func between(before, after string) string {
+	switch {
+	case before == "" && after == "":
+		return First
+	case after == "":
+		head, fraction := split(before)
+		if next, ok := increment(head); ok {
+			return next
+		}
+		return head + midpoint(fraction, "")
+	case before == "":
+		head, fraction := split(after)
+		previous, ok := decrement(head)
+		if !ok {
+			return head + midpoint("", fraction)
+		}
+		if strings.Trim(previous, "0") == "" {
+			// Nothing sorts before the smallest head, keep room with a fraction
+			return previous + "V"
+		}
+		return previous
+	}
+	beforeHead, beforeFraction := split(before)
+	afterHead, afterFraction := split(after)
+	if beforeHead == afterHead {
+		return beforeHead + midpoint(beforeFraction, afterFraction)
+	}
+	if next, ok := increment(beforeHead); ok && next < after {
+		return next
+	}
+	return beforeHead + midpoint(beforeFraction, "")
+}
+
+// split separate the integer head of the key from its fraction
+func split(key string) (string, string) {
+	if len(key) <= HeadLength {
+		return key + strings.Repeat("0", HeadLength-len(key)), ""
+	}
+	return key[:HeadLength], key[HeadLength:]
+}
+
+// increment add one to the head, false when it overflows
+func increment(head string) (string, bool) {
+	value := []byte(head)
+	for i := len(value) - 1; i >= 0; i-- {
+		index := strings.IndexByte(digits, value[i])
+		if index < len(digits)-1 {
+			value[i] = digits[index+1]
+			return string(value), true
+		}
+		value[i] = digits[0]
+	}
+	return head, false
+}
+
+// decrement subtract one from the head, false when it underflows
+func decrement(head string) (string, bool) {
+	value := []byte(head)
+	for i := len(value) - 1; i >= 0; i-- {
+		index := strings.IndexByte(digits, value[i])
+		if index > 0 {
+			value[i] = digits[index-1]
+			return string(value), true
+		}
+		value[i] = digits[len(digits)-1]
+	}
+	return head, false
+}
+
+// midpoint get a fraction sorting strictly between a and b, an empty b means no upper bound
+// The fractions never end with the zero digit, so there is always room before them
+func midpoint(a, b string) string {
+	if b != "" {
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+	low := 0
+	if a != "" {
+		low = strings.IndexByte(digits, a[0])
+	}
+	high := len(digits)
+	if b != "" {
+		high = strings.IndexByte(digits, b[0])
+	}
+	if high-low > 1 {
+		return string(digits[(low+high)/2])
+	}
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[low]) + midpoint(rest, "")
+}
+
+// digitAt get the digit of the key at the index, zero past its end
+func digitAt(key string, index int) byte {
+	if index < len(key) {
+		return key[index]
+	}
+	return digits[0]
+}
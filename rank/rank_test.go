@@ -0,0 +1,112 @@
+package rank
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		name    string
+		before  string
+		after   string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty sequence", want: First},
+		{name: "append", before: "V000000000", want: "V000000001"},
+		{name: "append carries", before: "V00000000z", want: "V000000010"},
+		{name: "append after the greatest head", before: "zzzzzzzzzz", want: "zzzzzzzzzzV"},
+		{name: "prepend", after: "V000000000", want: "Uzzzzzzzzz"},
+		{name: "prepend before the smallest head", after: "0000000001", want: "0000000000V"},
+		{name: "prepend inside the smallest head", after: "0000000000V", want: "0000000000F"},
+		{name: "prepend before the smallest key", after: "0000000000", wantErr: true},
+		{name: "prepend before a fraction of zeros", after: "00000000000", wantErr: true},
+		{name: "consecutive heads", before: "V000000000", after: "V000000001", want: "V000000000V"},
+		{name: "heads with room", before: "V000000000", after: "V000000005", want: "V000000001"},
+		{name: "same head", before: "V000000000V", after: "V000000000W", want: "V000000000VV"},
+		{name: "shared fraction prefix", before: "V000000000V1", after: "V000000000V3", want: "V000000000V2"},
+		{name: "adjacent fractions", before: "V000000000V", after: "V000000000V1", want: "V000000000V0V"},
+		{name: "equal keys", before: "V000000000", after: "V000000000", wantErr: true},
+		{name: "reversed keys", before: "V000000001", after: "V000000000", wantErr: true},
+		{name: "no room between a key and its zero extension", before: "V000000000", after: "V0000000000", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Between(test.before, test.after)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("Between(%q, %q) = %q, want an error", test.before, test.after, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Between(%q, %q): %v", test.before, test.after, err)
+			}
+			if got != test.want {
+				t.Fatalf("Between(%q, %q) = %q, want %q", test.before, test.after, got, test.want)
+			}
+		})
+	}
+}
+
+// TestBetweenKeepsOrder insert keys at random places and check the sequence stays strictly ordered
+func TestBetweenKeepsOrder(t *testing.T) {
+	random := rand.New(rand.NewSource(42))
+	for run := 0; run < 20; run++ {
+		keys := []string{}
+		for i := 0; i < 500; i++ {
+			index := random.Intn(len(keys) + 1)
+			// Insert at both ends often, as the appends and the prepends of the users
+			switch random.Intn(4) {
+			case 0:
+				index = 0
+			case 1:
+				index = len(keys)
+			}
+			before, after := "", ""
+			if index > 0 {
+				before = keys[index-1]
+			}
+			if index < len(keys) {
+				after = keys[index]
+			}
+			key, err := Between(before, after)
+			if err != nil {
+				t.Fatalf("Between(%q, %q): %v", before, after, err)
+			}
+			if (before != "" && key <= before) || (after != "" && key >= after) {
+				t.Fatalf("Between(%q, %q) = %q, out of its bounds", before, after, key)
+			}
+			if len(key) > HeadLength && strings.HasSuffix(key, "0") {
+				t.Fatalf("Between(%q, %q) = %q, the fraction ends with a zero", before, after, key)
+			}
+			keys = append(keys[:index], append([]string{key}, keys[index:]...)...)
+		}
+		if !sort.StringsAreSorted(keys) {
+			t.Fatalf("keys are not sorted: %v", keys)
+		}
+		for i := 1; i < len(keys); i++ {
+			if keys[i-1] == keys[i] {
+				t.Fatalf("duplicate key %q", keys[i])
+			}
+		}
+	}
+}
+
+// TestBetweenRepeatedAtSamePlace insert again and again right after the same key, the worst case for the fractions
+func TestBetweenRepeatedAtSamePlace(t *testing.T) {
+	before, after := "V000000000", "V000000001"
+	for i := 0; i < 200; i++ {
+		key, err := Between(before, after)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", before, after, err)
+		}
+		if key <= before || key >= after {
+			t.Fatalf("Between(%q, %q) = %q, out of its bounds", before, after, key)
+		}
+		after = key
+	}
+}
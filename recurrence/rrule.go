@@ -0,0 +1,90 @@
+package recurrence
+
+import (
+	"fmt"
+	"github.com/teambition/rrule-go"
+	"strings"
+	"time"
+)
+
+// MaxPreview maximum number of occurrences returned by Preview
+const MaxPreview int = 100
+
+// parse read a RFC 5545 recurrence, the times without TZID are read in loc
+// The rule may be a bare "FREQ=...", a "RRULE:..." line or a set starting with "DTSTART..."
+func parse(rule string, loc *time.Location) (*rrule.Set, error) {
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(rule), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(strings.ToUpper(line), "FREQ=") {
+			line = "RRULE:" + line
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty recurrence rule")
+	}
+	set, setErr := rrule.StrSliceToRRuleSetInLoc(lines, loc)
+	if setErr != nil {
+		return nil, setErr
+	}
+	if set.GetRRule() == nil {
+		return nil, fmt.Errorf("recurrence must contain a RRULE")
+	}
+	return set, nil
+}
+
+// Normalize validate the rule and pin its DTSTART
+// dtstart is used when the rule does not have its own DTSTART
+// Return the rule as stored in the database
+func Normalize(rule string, dtstart time.Time) (string, error) {
+	set, setErr := parse(rule, dtstart.Location())
+	if setErr != nil {
+		return "", fmt.Errorf("invalid recurrence, %s", setErr.Error())
+	}
+	// Finer rules would make every lookup walk millions of occurrences
+	if set.GetRRule().OrigOptions.Freq > rrule.HOURLY {
+		return "", fmt.Errorf("invalid recurrence, the smallest frequency is HOURLY")
+	}
+	if set.GetDTStart().IsZero() {
+		set.DTStart(dtstart)
+	}
+	return set.String(), nil
+}
+
+// Next get the first occurrence of the rule strictly after the given time
+// Return false when the recurrence is over
+func Next(rule string, after time.Time) (time.Time, bool) {
+	set, setErr := parse(rule, time.UTC)
+	if setErr != nil {
+		return time.Time{}, false
+	}
+	next := set.After(after, false)
+	return next, !next.IsZero()
+}
+
+// Preview get the next count occurrences of the rule after the given time
+func Preview(rule string, after time.Time, count int) ([]time.Time, error) {
+	set, setErr := parse(rule, time.UTC)
+	if setErr != nil {
+		return nil, setErr
+	}
+	if count > MaxPreview {
+		count = MaxPreview
+	}
+	occurrences := []time.Time{}
+	next := set.Iterator()
+	for len(occurrences) < count {
+		occurrence, hasNext := next()
+		if !hasNext {
+			break
+		}
+		if occurrence.After(after) {
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	return occurrences, nil
+}
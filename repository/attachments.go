@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/storage"
+)
+
+// PurgeAttachmentError error of an attachment failing to be purged, its blob is kept for the next purge
+const PurgeAttachmentError string = "can not purge attachment %d, %s"
+
+const attachmentColumns string = "attachment_id, item_id, user_id, filename, content_type, size, storage_key, created_at"
+
+type AttachmentsRepository struct {
+	Db *sql.DB
+}
+
+// scanAttachment scan a row selected with attachmentColumns into the attachment
+func scanAttachment(row rowScanner, attachment *model.Attachment) error {
+	return row.Scan(
+		&attachment.AttachmentId,
+		&attachment.ItemId,
+		&attachment.UserId,
+		&attachment.Filename,
+		&attachment.ContentType,
+		&attachment.Size,
+		&attachment.StorageKey,
+		&attachment.CreatedAt,
+	)
+}
+
+// Insert method of AttachmentsRepository
+// @param attachment
+// @throw error
+func (attachmentsRepository AttachmentsRepository) Insert(attachment *model.Attachment) error {
+	result, err := attachmentsRepository.Db.Exec(
+		"INSERT INTO attachments (item_id, user_id, filename, content_type, size, storage_key) values (?, ?, ?, ?, ?, ?)",
+		attachment.ItemId,
+		attachment.UserId,
+		attachment.Filename,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.StorageKey,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	attachment.AttachmentId = uint64(lastInsertId)
+	return nil
+}
+
+// Find method of AttachmentsRepository
+// The attachments of the deleted items can not be found
+// @param id
+// @return attachment
+// @throw error
+func (attachmentsRepository AttachmentsRepository) Find(id uint64) (model.Attachment, error) {
+	var attachment model.Attachment
+	exec := "SELECT " + attachmentColumns + " FROM attachments WHERE attachment_id = ? and item_id IS NOT NULL"
+	if queryErr := scanAttachment(attachmentsRepository.Db.QueryRow(exec, id), &attachment); queryErr != nil {
+		return model.Attachment{}, queryErr
+	}
+	return attachment, nil
+}
+
+// FindAll method of AttachmentsRepository
+// @param itemID
+// @return attachments, the oldest first
+// @throw error
+func (attachmentsRepository AttachmentsRepository) FindAll(itemID uint64) ([]model.Attachment, error) {
+	rows, err := attachmentsRepository.Db.Query(
+		"SELECT "+attachmentColumns+" FROM attachments WHERE item_id = ? order by attachment_id",
+		itemID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []model.Attachment{}
+	for rows.Next() {
+		var attachment model.Attachment
+		if scanErr := scanAttachment(rows, &attachment); scanErr != nil {
+			return nil, scanErr
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// FindIDsOfTree method of AttachmentsRepository
+// Find the attachments of the item, and of its subtasks at any depth when descendants is set
+// @param itemID
+// @param descendants
+// @return attachment IDs
+// @throw error
+func (attachmentsRepository AttachmentsRepository) FindIDsOfTree(itemID uint64, descendants bool) ([]uint64, error) {
+	exec := "SELECT attachment_id FROM attachments WHERE item_id = ?"
+	if descendants {
+		exec = "WITH RECURSIVE tree (item_id) AS (" +
+			"SELECT item_id FROM items WHERE item_id = ?" +
+			" UNION ALL SELECT i.item_id FROM items i JOIN tree ON i.parent_id = tree.item_id" +
+			") SELECT attachment_id FROM attachments WHERE item_id IN (SELECT item_id FROM tree)"
+	}
+	rows, err := attachmentsRepository.Db.Query(exec, itemID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []uint64{}
+	for rows.Next() {
+		var id uint64
+		if scanErr := rows.Scan(&id); scanErr != nil {
+			return nil, scanErr
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Delete method of AttachmentsRepository
+// Detach the attachment from its item, its blob is removed by PurgeDetached
+// @param attachmentId
+// @throw error
+func (attachmentsRepository AttachmentsRepository) Delete(attachmentId uint64) error {
+	_, deletedError := attachmentsRepository.Db.Exec(
+		"UPDATE attachments set item_id = NULL WHERE attachment_id = ?",
+		attachmentId,
+	)
+	return deletedError
+}
+
+// PurgeDetached method of AttachmentsRepository
+// Remove the blobs of the attachments left without item, then the attachments
+// Only the given attachments are purged when there are some, the attached ones are skipped
+// The item is detached by the database when it is deleted, so a rolled back delete never loses a blob
+// An attachment failing to be purged does not stop the others, it is tried again by the next purge
+// @param ctx
+// @param blobs
+// @param attachmentIDs
+// @return number of purged attachments
+// @throw error joined errors of the failed attachments
+func (attachmentsRepository AttachmentsRepository) PurgeDetached(ctx context.Context, blobs storage.BlobStore, attachmentIDs ...uint64) (int, error) {
+	exec := "SELECT attachment_id, storage_key FROM attachments WHERE item_id IS NULL and attachment_id > ?"
+	args := []any{}
+	if len(attachmentIDs) > 0 {
+		exec += " and attachment_id IN (" + placeholders(len(attachmentIDs)) + ")"
+		args = idArgs(attachmentIDs)
+	}
+	exec += " order by attachment_id LIMIT ?"
+	remove := func(attachmentID uint64) error {
+		_, deletedErr := attachmentsRepository.Db.Exec("DELETE FROM attachments WHERE attachment_id = ?", attachmentID)
+		return deletedErr
+	}
+	purged := 0
+	failures := []error{}
+	var lastID uint64
+	for {
+		rows, err := attachmentsRepository.Db.Query(exec, append(append([]any{lastID}, args...), jobBatchSize)...)
+		if err != nil {
+			return purged, errors.Join(append(failures, err)...)
+		}
+		detached := []model.Attachment{}
+		for rows.Next() {
+			var attachment model.Attachment
+			if scanErr := rows.Scan(&attachment.AttachmentId, &attachment.StorageKey); scanErr != nil {
+				rows.Close()
+				return purged, errors.Join(append(failures, scanErr)...)
+			}
+			detached = append(detached, attachment)
+		}
+		rows.Close()
+		if rowsErr := rows.Err(); rowsErr != nil {
+			return purged, errors.Join(append(failures, rowsErr)...)
+		}
+
+		batchPurged, batchErr := purgeBlobs(ctx, blobs, detached, remove)
+		purged += batchPurged
+		if batchErr != nil {
+			failures = append(failures, batchErr)
+		}
+		if len(detached) < jobBatchSize || ctx.Err() != nil {
+			return purged, errors.Join(failures...)
+		}
+		lastID = detached[len(detached)-1].AttachmentId
+	}
+}
+
+// purgeBlobs remove the blob of each attachment, then the attachment with remove
+// A failed attachment is skipped so it does not block the others, the errors are joined
+func purgeBlobs(ctx context.Context, blobs storage.BlobStore, detached []model.Attachment, remove func(attachmentID uint64) error) (int, error) {
+	purged := 0
+	failures := []error{}
+	for _, attachment := range detached {
+		if ctx.Err() != nil {
+			failures = append(failures, ctx.Err())
+			break
+		}
+		if deleteErr := blobs.Delete(ctx, attachment.StorageKey); deleteErr != nil {
+			failures = append(failures, fmt.Errorf(PurgeAttachmentError, attachment.AttachmentId, deleteErr.Error()))
+			continue
+		}
+		if removeErr := remove(attachment.AttachmentId); removeErr != nil {
+			failures = append(failures, fmt.Errorf(PurgeAttachmentError, attachment.AttachmentId, removeErr.Error()))
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(failures...)
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/storage"
+	"io"
+	"strings"
+	"testing"
+)
+
+// failingStore blob store failing to delete one key
+type failingStore struct {
+	failKey string
+	deleted []string
+}
+
+func (store *failingStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	return nil
+}
+
+func (store *failingStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	return nil, storage.ErrBlobNotFound
+}
+
+func (store *failingStore) Delete(ctx context.Context, key string) error {
+	if key == store.failKey {
+		return errors.New("access denied")
+	}
+	store.deleted = append(store.deleted, key)
+	return nil
+}
+
+func TestPurgeBlobsSkipsFailedAttachment(t *testing.T) {
+	store := &failingStore{failKey: "b"}
+	detached := []model.Attachment{
+		{AttachmentId: 1, StorageKey: "a"},
+		{AttachmentId: 2, StorageKey: "b"},
+		{AttachmentId: 3, StorageKey: "c"},
+	}
+	removed := []uint64{}
+	remove := func(attachmentID uint64) error {
+		removed = append(removed, attachmentID)
+		return nil
+	}
+
+	purged, err := purgeBlobs(context.Background(), store, detached, remove)
+	if purged != 2 {
+		t.Fatalf("purged %d attachments, want 2", purged)
+	}
+	if err == nil || !strings.Contains(err.Error(), "attachment 2") {
+		t.Fatalf("error %v, want the failure of attachment 2", err)
+	}
+	if strings.Join(store.deleted, ",") != "a,c" {
+		t.Fatalf("deleted blobs %v, want a and c", store.deleted)
+	}
+	if len(removed) != 2 || removed[0] != 1 || removed[1] != 3 {
+		t.Fatalf("removed attachments %v, want 1 and 3, the failed one is kept for the next purge", removed)
+	}
+}
+
+func TestPurgeBlobsKeepsBlobWhenRemoveFails(t *testing.T) {
+	store := &failingStore{}
+	detached := []model.Attachment{
+		{AttachmentId: 1, StorageKey: "a"},
+		{AttachmentId: 2, StorageKey: "b"},
+	}
+	remove := func(attachmentID uint64) error {
+		if attachmentID == 1 {
+			return errors.New("connection lost")
+		}
+		return nil
+	}
+
+	purged, err := purgeBlobs(context.Background(), store, detached, remove)
+	if purged != 1 || err == nil {
+		t.Fatalf("purged %d attachments with error %v, want 1 and an error", purged, err)
+	}
+}
+
+func TestPurgeBlobsStopsWhenCancelled(t *testing.T) {
+	store := &failingStore{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	purged, err := purgeBlobs(ctx, store, []model.Attachment{{AttachmentId: 1, StorageKey: "a"}}, func(uint64) error {
+		return nil
+	})
+	if purged != 0 || !errors.Is(err, context.Canceled) {
+		t.Fatalf("purged %d attachments with error %v, want none and context.Canceled", purged, err)
+	}
+	if len(store.deleted) != 0 {
+		t.Fatalf("deleted blobs %v after the cancel", store.deleted)
+	}
+}
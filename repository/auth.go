@@ -45,9 +45,14 @@ func (authRepository AuthRepository) ComparePasswordHash(hashedPassword, passwor
 	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 }
 
-// CreateToken Create a token base on username
-func (authRepository AuthRepository) CreateToken(username string) (string, error) {
-	return auth.Create(username)
+// CreateToken Create an access token for the user, in the login session of the family of refresh tokens
+func (authRepository AuthRepository) CreateToken(user model.User, familyID string) (string, error) {
+	return auth.Create(user.UserId, user.Username, user.TokenVersion, familyID)
+}
+
+// RevokeToken Revoke the access token until its expiry
+func (authRepository AuthRepository) RevokeToken(token string) error {
+	return auth.Revoke(token)
 }
 
 // ParseToken Parse the token
@@ -65,6 +70,16 @@ func (authRepository AuthRepository) GetUserIDFromToken(token string) (uint64, e
 	return auth.GetUserIDFromToken(token)
 }
 
+// GetUserIDFromAccessToken Parse the access token, refuse the refresh tokens
+func (authRepository AuthRepository) GetUserIDFromAccessToken(token string) (uint64, error) {
+	return auth.GetUserIDFromAccessToken(token)
+}
+
+// ParseAccessToken Parse the access token, refuse the refresh tokens
+func (authRepository AuthRepository) ParseAccessToken(token string) (auth.AccessClaims, error) {
+	return auth.ParseAccessToken(token)
+}
+
 // GetErrorMessageByCode Get error message by code
 func (authRepository AuthRepository) GetErrorMessageByCode(code int) string {
 	mappingError := map[int]string{
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+)
+
+const commentColumns string = "c.comment_id, c.item_id, c.user_id, u.username, c.body, c.created_at, c.updated_at"
+
+type CommentsRepository struct {
+	Db *sql.DB
+}
+
+// scanComment scan a row selected with commentColumns into the comment
+func scanComment(row rowScanner, comment *model.Comment) error {
+	return row.Scan(
+		&comment.CommentId,
+		&comment.ItemId,
+		&comment.UserId,
+		&comment.Username,
+		&comment.Body,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+	)
+}
+
+// Insert method of CommentsRepository
+// @param comment
+// @throw error
+func (commentsRepository CommentsRepository) Insert(comment *model.Comment) error {
+	result, err := commentsRepository.Db.Exec(
+		"INSERT INTO comments (item_id, user_id, body) values (?, ?, ?)",
+		comment.ItemId,
+		comment.UserId,
+		comment.Body,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	comment.CommentId = uint64(lastInsertId)
+	return nil
+}
+
+// Find method of CommentsRepository
+// @param id
+// @param itemID
+// @return comment
+// @throw error
+func (commentsRepository CommentsRepository) Find(id, itemID uint64) (model.Comment, error) {
+	var comment model.Comment
+	exec := "SELECT " + commentColumns + " FROM comments c JOIN users u ON u.user_id = c.user_id" +
+		" WHERE c.comment_id = ? and c.item_id = ?"
+	if queryErr := scanComment(commentsRepository.Db.QueryRow(exec, id, itemID), &comment); queryErr != nil {
+		return model.Comment{}, queryErr
+	}
+	return comment, nil
+}
+
+// FindAll method of CommentsRepository
+// @param itemID
+// @param limit
+// @param offset
+// @return comments, the oldest first
+// @throw error
+func (commentsRepository CommentsRepository) FindAll(itemID uint64, limit, offset int) ([]model.Comment, error) {
+	rows, err := commentsRepository.Db.Query(
+		"SELECT "+commentColumns+" FROM comments c JOIN users u ON u.user_id = c.user_id"+
+			" WHERE c.item_id = ? order by c.comment_id LIMIT ? OFFSET ?",
+		itemID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := []model.Comment{}
+	for rows.Next() {
+		var comment model.Comment
+		if scanErr := scanComment(rows, &comment); scanErr != nil {
+			return nil, scanErr
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+// Update method of CommentsRepository
+// @param comment
+// @param commentInput
+// @throw error
+func (commentsRepository CommentsRepository) Update(comment *model.Comment, commentInput *model.CommentInput) error {
+	_, updatedError := commentsRepository.Db.Exec(
+		"UPDATE comments set body = ? WHERE comment_id = ?",
+		commentInput.Body,
+		comment.CommentId,
+	)
+	return updatedError
+}
+
+// Delete method of CommentsRepository
+// @param commentId
+// @throw error
+func (commentsRepository CommentsRepository) Delete(commentId uint64) error {
+	_, deletedError := commentsRepository.Db.Exec(
+		"DELETE FROM comments WHERE comment_id = ?",
+		commentId,
+	)
+	return deletedError
+}
@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+)
+
+const historyColumns string = "history_id, item_id, version, action, actor_id, request_id, changes, snapshot, created_at"
+
+// itemAudit state of the items before a change, recorded in the history once the change is made
+type itemAudit struct {
+	tx     *sql.Tx
+	actor  model.Actor
+	before map[uint64]model.Item
+	ids    []uint64
+}
+
+// in get the repository running inside the transaction
+func (itemsRepository ItemsRepository) in(tx *sql.Tx) ItemsRepository {
+	return ItemsRepository{Db: itemsRepository.Db, Tx: tx, Actor: itemsRepository.Actor, Versions: itemsRepository.Versions}
+}
+
+// audit load and lock the state of the items before a change made in the transaction
+// Fail with ErrVersionConflict when one of the items is not at the version expected by the repository
+func (itemsRepository ItemsRepository) audit(tx *sql.Tx, ids ...uint64) (*itemAudit, error) {
+	before, err := itemsRepository.in(tx).snapshot(ids)
+	if err != nil {
+		return nil, err
+	}
+	for id, item := range before {
+		if expected, found := itemsRepository.Versions[id]; found && item.Version != expected {
+			return nil, ErrVersionConflict
+		}
+	}
+	return &itemAudit{tx: tx, actor: itemsRepository.Actor, before: before, ids: ids}, nil
+}
+
+// snapshot load the items by ID, in the trash or not, and lock them until the end of the transaction
+func (itemsRepository ItemsRepository) snapshot(ids []uint64) (map[uint64]model.Item, error) {
+	found := map[uint64]model.Item{}
+	if len(ids) == 0 {
+		return found, nil
+	}
+	listItems, err := itemsRepository.queryItems(
+		"SELECT "+itemColumns+" FROM items WHERE item_id IN ("+placeholders(len(ids))+") FOR UPDATE",
+		idArgs(ids)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range listItems {
+		found[item.ItemId] = item
+	}
+	return found, nil
+}
+
+// track add the items created by the change
+func (audit *itemAudit) track(ids ...uint64) {
+	audit.ids = append(audit.ids, ids...)
+}
+
+// record append a history row for each item changed since the audit started
+// and increase the version of the changed items
+func (audit *itemAudit) record(action string) error {
+	after, err := ItemsRepository{Tx: audit.tx}.snapshot(audit.ids)
+	if err != nil {
+		return err
+	}
+	changed := []uint64{}
+	for _, id := range audit.ids {
+		previous, existed := audit.before[id]
+		current, exists := after[id]
+		var beforeFields, afterFields map[string]any
+		if existed {
+			beforeFields = model.HistoryFields(previous)
+		}
+		if exists {
+			afterFields = model.HistoryFields(current)
+		}
+		changes := model.DiffHistoryFields(beforeFields, afterFields)
+		if len(changes) == 0 {
+			continue
+		}
+		owner, snapshot := current.UserId, afterFields
+		if !exists {
+			owner, snapshot = previous.UserId, beforeFields
+		}
+		if insertErr := audit.insert(id, owner, action, changes, snapshot); insertErr != nil {
+			return insertErr
+		}
+		// The new items start at the default version
+		if existed && exists {
+			changed = append(changed, id)
+		}
+		if _, assigned := changes["assignee_id"]; assigned && exists {
+			if notifyErr := audit.notifyAssignee(previous, current); notifyErr != nil {
+				return notifyErr
+			}
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	_, updatedErr := audit.tx.Exec(
+		"UPDATE items set version = version + 1 WHERE item_id IN ("+placeholders(len(changed))+")",
+		idArgs(changed)...,
+	)
+	return updatedErr
+}
+
+// insert write the history row with the next version of the item
+func (audit *itemAudit) insert(itemID, userID uint64, action string, changes map[string]model.FieldChange, snapshot map[string]any) error {
+	encodedChanges, changesErr := json.Marshal(changes)
+	if changesErr != nil {
+		return changesErr
+	}
+	encodedSnapshot, snapshotErr := json.Marshal(snapshot)
+	if snapshotErr != nil {
+		return snapshotErr
+	}
+	var actorID, requestID any
+	if audit.actor.UserId != 0 {
+		actorID = audit.actor.UserId
+	}
+	if audit.actor.RequestId != "" {
+		requestID = audit.actor.RequestId
+	}
+	_, err := audit.tx.Exec(
+		"INSERT INTO item_history (item_id, user_id, version, action, actor_id, request_id, changes, snapshot)"+
+			" SELECT ?, ?, COALESCE(MAX(version), 0) + 1, ?, ?, ?, ?, ? FROM item_history WHERE item_id = ?",
+		itemID,
+		userID,
+		action,
+		actorID,
+		requestID,
+		encodedChanges,
+		encodedSnapshot,
+		itemID,
+	)
+	return err
+}
+
+// scanHistoryEntry scan a row selected with historyColumns into the entry
+func scanHistoryEntry(row rowScanner, entry *model.HistoryEntry) error {
+	var actorID sql.NullInt64
+	var requestID sql.NullString
+	var changes, snapshot []byte
+	scanErr := row.Scan(
+		&entry.HistoryId,
+		&entry.ItemId,
+		&entry.Version,
+		&entry.Action,
+		&actorID,
+		&requestID,
+		&changes,
+		&snapshot,
+		&entry.CreatedAt,
+	)
+	if scanErr != nil {
+		return scanErr
+	}
+	entry.ActorId = toUint64(actorID)
+	entry.RequestId = nil
+	if requestID.Valid {
+		entry.RequestId = &requestID.String
+	}
+	if changesErr := json.Unmarshal(changes, &entry.Changes); changesErr != nil {
+		return changesErr
+	}
+	return json.Unmarshal(snapshot, &entry.Snapshot)
+}
+
+// History method of ItemsRepository
+// The item may be in the trash or deleted for good
+// @param itemID
+// @param userID
+// @param limit
+// @param offset
+// @return history entries, the latest version first
+// @throw error
+func (itemsRepository ItemsRepository) History(itemID, userID uint64, limit, offset int) ([]model.HistoryEntry, error) {
+	rows, err := itemsRepository.conn().Query(
+		"SELECT "+historyColumns+" FROM item_history WHERE item_id = ? and user_id = ? order by version DESC LIMIT ? OFFSET ?",
+		itemID,
+		userID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []model.HistoryEntry{}
+	for rows.Next() {
+		var entry model.HistoryEntry
+		if scanErr := scanHistoryEntry(rows, &entry); scanErr != nil {
+			return nil, scanErr
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// FindVersion method of ItemsRepository
+// @param itemID
+// @param userID
+// @param version
+// @return history entry
+// @throw error
+func (itemsRepository ItemsRepository) FindVersion(itemID, userID uint64, version int) (model.HistoryEntry, error) {
+	var entry model.HistoryEntry
+	queryErr := scanHistoryEntry(itemsRepository.conn().QueryRow(
+		"SELECT "+historyColumns+" FROM item_history WHERE item_id = ? and user_id = ? and version = ?",
+		itemID,
+		userID,
+		version,
+	), &entry)
+	if queryErr != nil {
+		return model.HistoryEntry{}, queryErr
+	}
+	return entry, nil
+}
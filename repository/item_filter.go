@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"strings"
+)
+
+// TagModeAny match the items having at least one of the filter tags
+const TagModeAny string = "any"
+
+// TagModeAll match the items having all the filter tags
+const TagModeAll string = "all"
+
+// ItemFilter narrows down the items returned by FindAll and CountAll
+// The zero value of every field means no filter
+// Dates use the UTC database layout
+// The items shared with the user are included, Shared selects only them
+// AssigneeID selects the items assigned to the user with this ID
+// Trashed selects the items of the user in the trash, they are left out otherwise
+// Archived selects the archived items out of the trash, they are left out otherwise
+// Keyset is only used by FindAll, the count ignores the pagination
+type ItemFilter struct {
+	ListID         uint64
+	Tags           []string
+	TagMode        string
+	Statuses       []int
+	CreatedAfter   string
+	CreatedBefore  string
+	UpdatedSince   string
+	HasDescription *bool
+	IDs            []uint64
+	AssigneeID     uint64
+	Shared         bool
+	Trashed        bool
+	Archived       bool
+	Sort           []SortKey
+	Keyset         *Keyset
+}
+
+// queryBuilder collect the conditions of a WHERE clause with their arguments
+// The values are always passed as arguments, never concatenated to the query
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// where add a condition joined with "and"
+func (builder *queryBuilder) where(condition string, args ...any) {
+	builder.conditions = append(builder.conditions, condition)
+	builder.args = append(builder.args, args...)
+}
+
+// whereIn add a "column IN (...)" condition
+func whereIn[T any](builder *queryBuilder, column string, values []T) {
+	args := make([]any, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	builder.where(column+" IN ("+placeholders(len(values))+")", args...)
+}
+
+// String get the WHERE clause without the WHERE keyword
+func (builder *queryBuilder) String() string {
+	return strings.Join(builder.conditions, " and ")
+}
+
+// build get the WHERE clause and arguments of the filter for the user
+func (filter ItemFilter) build(userID uint64) (string, []any) {
+	builder := &queryBuilder{}
+	if filter.Trashed {
+		builder.where("user_id = ? and deleted_at IS NOT NULL", userID)
+	} else {
+		access, accessArgs := accessCondition(userID)
+		builder.where(access, accessArgs...)
+		if filter.Archived {
+			builder.where("deleted_at IS NULL and archived_at IS NOT NULL")
+		} else {
+			builder.where("deleted_at IS NULL and archived_at IS NULL")
+		}
+	}
+	if filter.Shared {
+		builder.where("user_id <> ?", userID)
+	}
+	if filter.ListID != 0 {
+		builder.where("list_id = ?", filter.ListID)
+	}
+	if filter.AssigneeID != 0 {
+		builder.where("assignee_id = ?", filter.AssigneeID)
+	}
+	if len(filter.Statuses) > 0 {
+		whereIn(builder, "status", filter.Statuses)
+	}
+	if filter.CreatedAfter != "" {
+		builder.where("created_at >= ?", filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != "" {
+		builder.where("created_at < ?", filter.CreatedBefore)
+	}
+	if filter.UpdatedSince != "" {
+		builder.where("updated_at >= ?", filter.UpdatedSince)
+	}
+	if filter.HasDescription != nil {
+		if *filter.HasDescription {
+			builder.where("description IS NOT NULL and description <> ''")
+		} else {
+			builder.where("(description IS NULL or description = '')")
+		}
+	}
+	if len(filter.IDs) > 0 {
+		whereIn(builder, "item_id", filter.IDs)
+	}
+	if len(filter.Tags) > 0 {
+		// The tags belong to the owner of the item, the shared items are matched by the names only
+		args := []any{}
+		for _, tag := range filter.Tags {
+			args = append(args, tag)
+		}
+		subQuery := "SELECT it.item_id FROM item_tags it JOIN tags t ON t.tag_id = it.tag_id" +
+			" WHERE t.name IN (" + placeholders(len(filter.Tags)) + ")"
+		if filter.TagMode == TagModeAll {
+			subQuery += " GROUP BY it.item_id HAVING COUNT(DISTINCT t.tag_id) = ?"
+			args = append(args, len(filter.Tags))
+		}
+		builder.where("item_id IN ("+subQuery+")", args...)
+	}
+	return builder.String(), builder.args
+}
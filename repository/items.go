@@ -2,70 +2,188 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
+	"fmt"
 	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/daniel-vuky/golang-todo-list-v2/rank"
+	"sort"
+	"strings"
+	"time"
 )
 
+const itemColumns string = "item_id, user_id, assignee_id, list_id, parent_id, title, description, status, priority, position, version, due_at, start_at, recurrence, completed_at, archived_at, created_at, updated_at, deleted_at"
+
+// completedAtAssignment keep completed_at in sync with the status, must come after the status in the SET clause
+// The completion date is kept while the item stays completed and cleared when it is reopened
+const completedAtAssignment string = "completed_at = IF(status = ?, COALESCE(completed_at, UTC_TIMESTAMP()), NULL)"
+
+// ErrVersionConflict returned when an item was changed since the version expected by IfVersion
+var ErrVersionConflict = errors.New("the item was changed since it was read")
+
+// jobBatchSize number of items loaded at once by PurgeTrash and ArchiveCompleted
+const jobBatchSize int = 500
+
+// ErrMoveTargetNotFound returned by Move when the target is not an item of the user outside the trash
+var ErrMoveTargetNotFound = errors.New("target item does not exist")
+
+// ItemsRepository works inside Tx when it is set, see Begin
+// The changes are recorded in the history as made by Actor, see WithActor
+// The changes of the items in Versions fail unless the items are still at these versions, see IfVersion
 type ItemsRepository struct {
-	Db *sql.DB
+	Db       *sql.DB
+	Tx       *sql.Tx
+	Actor    model.Actor
+	Versions map[uint64]int
 }
 
-// Insert method of ItemsRepository
-// @param item
-// @throw error
-func (itemsRepository ItemsRepository) Insert(item *model.Item) error {
-	result, err := itemsRepository.Db.Exec(
-		"INSERT INTO items (user_id, title, description, status) values (?, ?, ?, ?)",
-		item.UserId,
-		item.Title,
-		item.Description,
-		item.Status,
-	)
-	if err != nil {
-		return err
+// executor is implemented by both *sql.DB and *sql.Tx
+type executor interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// conn get the transaction of the repository when there is one, the database otherwise
+func (itemsRepository ItemsRepository) conn() executor {
+	if itemsRepository.Tx != nil {
+		return itemsRepository.Tx
 	}
-	lastInsertId, insertErr := result.LastInsertId()
-	if insertErr != nil {
-		return insertErr
+	return itemsRepository.Db
+}
+
+// transaction run fn in the transaction of the repository, or in a new one committed on success
+func (itemsRepository ItemsRepository) transaction(fn func(tx *sql.Tx) error) error {
+	if itemsRepository.Tx != nil {
+		return fn(itemsRepository.Tx)
 	}
-	item.ItemId = uint64(lastInsertId)
-	return nil
+	tx, txErr := itemsRepository.Db.Begin()
+	if txErr != nil {
+		return txErr
+	}
+	defer tx.Rollback()
+
+	if fnErr := fn(tx); fnErr != nil {
+		return fnErr
+	}
+	return tx.Commit()
 }
 
-// Find method of ItemsRepository
-// @param id
-// @return item
-// @throw error
-func (itemsRepository ItemsRepository) Find(id int, userID uint64) (model.Item, error) {
-	var item model.Item
-	exec := "SELECT * FROM items WHERE item_id = ? and user_id = ?"
-	queryErr := itemsRepository.Db.QueryRow(exec, id, userID).Scan(
+// Begin start a transaction
+// Return a repository running every method inside it, commit or rollback its Tx when done
+func (itemsRepository ItemsRepository) Begin() (ItemsRepository, error) {
+	tx, txErr := itemsRepository.Db.Begin()
+	if txErr != nil {
+		return itemsRepository, txErr
+	}
+	return itemsRepository.in(tx), nil
+}
+
+// WithActor get a repository recording its changes as made by the actor
+func (itemsRepository ItemsRepository) WithActor(actor model.Actor) ItemsRepository {
+	itemsRepository.Actor = actor
+	return itemsRepository
+}
+
+// IfVersion get a repository failing the changes of the item with ErrVersionConflict
+// when the item is not at the version anymore
+func (itemsRepository ItemsRepository) IfVersion(itemID uint64, version int) ItemsRepository {
+	versions := map[uint64]int{itemID: version}
+	for id, expected := range itemsRepository.Versions {
+		if id != itemID {
+			versions[id] = expected
+		}
+	}
+	itemsRepository.Versions = versions
+	return itemsRepository
+}
+
+// Savepoint create a savepoint in the transaction of the repository
+func (itemsRepository ItemsRepository) Savepoint(name string) error {
+	_, err := itemsRepository.Tx.Exec("SAVEPOINT " + name)
+	return err
+}
+
+// RollbackToSavepoint cancel the changes made after the savepoint
+func (itemsRepository ItemsRepository) RollbackToSavepoint(name string) error {
+	_, err := itemsRepository.Tx.Exec("ROLLBACK TO SAVEPOINT " + name)
+	return err
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanItem scan a row selected with itemColumns into the item
+func scanItem(row rowScanner, item *model.Item) error {
+	var assigneeID, listID, parentID sql.NullInt64
+	var description, dueAt, startAt, recurrence, completedAt, archivedAt, deletedAt sql.NullString
+	scanErr := row.Scan(
 		&item.ItemId,
 		&item.UserId,
+		&assigneeID,
+		&listID,
+		&parentID,
 		&item.Title,
-		&item.Description,
+		&description,
 		&item.Status,
+		&item.Priority,
+		&item.Position,
+		&item.Version,
+		&dueAt,
+		&startAt,
+		&recurrence,
+		&completedAt,
+		&archivedAt,
 		&item.CreatedAt,
 		&item.UpdatedAt,
+		&deletedAt,
 	)
-	if queryErr != nil {
-		return model.Item{}, queryErr
+	if scanErr != nil {
+		return scanErr
 	}
+	item.AssigneeId = toUint64(assigneeID)
+	item.ListId = toUint64(listID)
+	item.ParentId = toUint64(parentID)
+	item.Description = description.String
+	item.DueAt = toRFC3339(dueAt)
+	item.StartAt = toRFC3339(startAt)
+	item.CompletedAt = toRFC3339(completedAt)
+	item.ArchivedAt = toRFC3339(archivedAt)
+	item.DeletedAt = toRFC3339(deletedAt)
+	item.Recurrence = nil
+	if recurrence.Valid {
+		item.Recurrence = &recurrence.String
+	}
+	return nil
+}
 
-	return item, nil
+// toUint64 convert a nullable ID column
+func toUint64(value sql.NullInt64) *uint64 {
+	if !value.Valid {
+		return nil
+	}
+	id := uint64(value.Int64)
+	return &id
 }
 
-// FindAll method of ItemsRepository
-// @param limit
-// @param offset
-// @return list item
-// @throw error
-func (itemsRepository ItemsRepository) FindAll(limit, offset int, userID uint64) ([]model.Item, error) {
-	items, err := itemsRepository.Db.Query(
-		"SELECT * FROM items where user_id = ? order by status, item_id LIMIT ? OFFSET ?",
-		userID,
-		limit,
-		offset,
-	)
+// placeholders build the "?, ?, ?" list for an IN clause
+func placeholders(count int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", count), ", ")
+}
+
+// idArgs convert the IDs to query arguments
+func idArgs(ids []uint64) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+// queryItems run the select query and load the tags of the found items
+func (itemsRepository ItemsRepository) queryItems(exec string, args ...any) ([]model.Item, error) {
+	items, err := itemsRepository.conn().Query(exec, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -74,45 +192,863 @@ func (itemsRepository ItemsRepository) FindAll(limit, offset int, userID uint64)
 	listItems := []model.Item{}
 	for items.Next() {
 		var item model.Item
-		if scanErr := items.Scan(
-			&item.ItemId,
-			&item.UserId,
-			&item.Title,
-			&item.Description,
-			&item.Status,
-			&item.CreatedAt,
-			&item.UpdatedAt,
-		); scanErr != nil {
+		if scanErr := scanItem(items, &item); scanErr != nil {
 			return nil, scanErr
 		}
 		listItems = append(listItems, item)
 	}
+	if rowsErr := items.Err(); rowsErr != nil {
+		return nil, rowsErr
+	}
+
+	return listItems, itemsRepository.attachTags(listItems)
+}
+
+// attachTags load the tag names of the items
+func (itemsRepository ItemsRepository) attachTags(items []model.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+	args := make([]any, len(items))
+	indexes := make(map[uint64]int, len(items))
+	for i := range items {
+		items[i].Tags = []string{}
+		args[i] = items[i].ItemId
+		indexes[items[i].ItemId] = i
+	}
+	rows, err := itemsRepository.conn().Query(
+		"SELECT it.item_id, t.name FROM item_tags it JOIN tags t ON t.tag_id = it.tag_id WHERE it.item_id IN ("+placeholders(len(args))+") order by t.name",
+		args...,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var itemID uint64
+		var name string
+		if scanErr := rows.Scan(&itemID, &name); scanErr != nil {
+			return scanErr
+		}
+		if i, found := indexes[itemID]; found {
+			items[i].Tags = append(items[i].Tags, name)
+		}
+	}
+
+	return rows.Err()
+}
+
+// toRFC3339 convert a nullable UTC datetime column to RFC 3339
+func toRFC3339(value sql.NullString) *string {
+	if !value.Valid {
+		return nil
+	}
+	parsed, parseErr := time.ParseInLocation(model.DatabaseTimeLayout, value.String, time.UTC)
+	if parseErr != nil {
+		return &value.String
+	}
+	formatted := parsed.Format(time.RFC3339)
+	return &formatted
+}
+
+// Insert method of ItemsRepository
+// The item is appended after the other items of the user unless its position is set
+// The positions of the user are locked until the item is inserted so concurrent inserts get distinct positions, see lockPositions
+// The tags of the item are set when they are not nil
+// @param item
+// @throw error
+func (itemsRepository ItemsRepository) Insert(item *model.Item) error {
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		if lockErr := lockPositions(tx, item.UserId); lockErr != nil {
+			return lockErr
+		}
+		audit, auditErr := itemsRepository.audit(tx)
+		if auditErr != nil {
+			return auditErr
+		}
+		if item.Position == "" {
+			last, lastErr := itemsRepository.in(tx).LastPosition(item.UserId)
+			if lastErr != nil {
+				return lastErr
+			}
+			position, positionErr := rank.Between(last, "")
+			if positionErr != nil {
+				return positionErr
+			}
+			item.Position = position
+		}
+		result, err := tx.Exec(
+			"INSERT INTO items (user_id, assignee_id, list_id, parent_id, title, description, status, priority, position, due_at, start_at, recurrence, completed_at) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, IF(? = ?, UTC_TIMESTAMP(), NULL))",
+			item.UserId,
+			item.AssigneeId,
+			item.ListId,
+			item.ParentId,
+			item.Title,
+			item.Description,
+			item.Status,
+			item.Priority,
+			item.Position,
+			item.DueAt,
+			item.StartAt,
+			item.Recurrence,
+			item.Status,
+			model.StatusCompleted,
+		)
+		if err != nil {
+			return err
+		}
+		lastInsertId, insertErr := result.LastInsertId()
+		if insertErr != nil {
+			return insertErr
+		}
+		item.ItemId = uint64(lastInsertId)
+		item.Version = 1
+		if item.Tags != nil {
+			if tagsErr := setTags(tx, item.ItemId, item.UserId, item.Tags); tagsErr != nil {
+				return tagsErr
+			}
+		}
+		audit.track(item.ItemId)
+		return audit.record(model.HistoryCreate)
+	})
+}
+
+// Find method of ItemsRepository
+// Find the item of the user or shared with the user
+// @param id
+// @return item
+// @throw error
+func (itemsRepository ItemsRepository) Find(id int, userID uint64) (model.Item, error) {
+	return itemsRepository.find(id, userID, false)
+}
+
+// FindTrashed method of ItemsRepository
+// Find the item only when it is in the trash, the trash is only seen by the owner of the item
+// @param id
+// @return item
+// @throw error
+func (itemsRepository ItemsRepository) FindTrashed(id int, userID uint64) (model.Item, error) {
+	return itemsRepository.find(id, userID, true)
+}
 
+// find the item in the trash of the user, or out of it among the items the user can access
+func (itemsRepository ItemsRepository) find(id int, userID uint64, trashed bool) (model.Item, error) {
+	var item model.Item
+	access, args := accessCondition(userID)
+	exec := "SELECT " + itemColumns + " FROM items WHERE item_id = ? and " + access + " and deleted_at IS NULL"
+	if trashed {
+		exec, args = "SELECT "+itemColumns+" FROM items WHERE item_id = ? and user_id = ? and deleted_at IS NOT NULL", []any{userID}
+	}
+	queryErr := scanItem(itemsRepository.conn().QueryRow(exec, append([]any{id}, args...)...), &item)
+	if queryErr != nil {
+		return model.Item{}, queryErr
+	}
+	found := []model.Item{item}
+	if tagsErr := itemsRepository.attachTags(found); tagsErr != nil {
+		return model.Item{}, tagsErr
+	}
+
+	return found[0], nil
+}
+
+// FindAll method of ItemsRepository
+// Start after or before filter.Keyset when it is set, instead of the offset
+// @param limit
+// @param offset
+// @param filter
+// @return list item
+// @throw error
+func (itemsRepository ItemsRepository) FindAll(limit, offset int, userID uint64, filter ItemFilter) ([]model.Item, error) {
+	where, args := filter.build(userID)
+	backward := false
+	if filter.Keyset != nil {
+		condition, keysetArgs, keysetErr := keysetCondition(filter.Sort, *filter.Keyset)
+		if keysetErr != nil {
+			return nil, keysetErr
+		}
+		where += " and " + condition
+		args = append(args, keysetArgs...)
+		backward = filter.Keyset.Backward
+	}
+	exec := "SELECT " + itemColumns + " FROM items WHERE " + where
+	exec += " order by " + orderBy(filter.Sort, backward) + " LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	listItems, queryErr := itemsRepository.queryItems(exec, args...)
+	if queryErr != nil {
+		return nil, queryErr
+	}
+	// Backward pages are read in reverse order
+	if backward {
+		for i, j := 0, len(listItems)-1; i < j; i, j = i+1, j-1 {
+			listItems[i], listItems[j] = listItems[j], listItems[i]
+		}
+	}
 	return listItems, nil
 }
 
+// FindDueBetween method of ItemsRepository
+// Find the not completed items due in [from, to) the user can access, ordered by due date
+// @param limit
+// @param offset
+// @param from UTC lower bound, no bound when empty
+// @param to UTC upper bound, no bound when empty
+// @return list item
+// @throw error
+func (itemsRepository ItemsRepository) FindDueBetween(limit, offset int, userID uint64, from, to string) ([]model.Item, error) {
+	access, args := accessCondition(userID)
+	exec := "SELECT " + itemColumns + " FROM items where " + access + " and deleted_at IS NULL and archived_at IS NULL and status <> ? and due_at is not null"
+	args = append(args, model.StatusCompleted)
+	if from != "" {
+		exec += " and due_at >= ?"
+		args = append(args, from)
+	}
+	if to != "" {
+		exec += " and due_at < ?"
+		args = append(args, to)
+	}
+	exec += " order by due_at, item_id LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	return itemsRepository.queryItems(exec, args...)
+}
+
+// patchableColumns columns accepted by UpdateFields
+var patchableColumns = map[string]bool{
+	"assignee_id": true,
+	"list_id":     true,
+	"parent_id":   true,
+	"title":       true,
+	"description": true,
+	"status":      true,
+	"priority":    true,
+	"due_at":      true,
+	"start_at":    true,
+	"recurrence":  true,
+}
+
+// UpdateFields method of ItemsRepository
+// Update only the given columns, the SET clause is built from the whitelisted columns
+// @param itemID
+// @param fields column name to new value
+// @param tags new tags of the item, nil keeps them
+// @throw error
+func (itemsRepository ItemsRepository) UpdateFields(itemID uint64, fields map[string]any, tags []string) error {
+	columns := make([]string, 0, len(fields))
+	for column := range fields {
+		if !patchableColumns[column] {
+			return fmt.Errorf("can not update column %q", column)
+		}
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	assignments := make([]string, len(columns))
+	args := make([]any, 0, len(columns)+1)
+	for i, column := range columns {
+		assignments[i] = column + " = ?"
+		args = append(args, fields[column])
+	}
+	assignments = append(assignments, completedAtAssignment)
+	args = append(args, model.StatusCompleted, itemID)
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		audit, auditErr := itemsRepository.audit(tx, itemID)
+		if auditErr != nil {
+			return auditErr
+		}
+		if len(columns) > 0 {
+			if _, updatedError := tx.Exec(
+				"UPDATE items set "+strings.Join(assignments, ", ")+" WHERE item_id = ?",
+				args...,
+			); updatedError != nil {
+				return updatedError
+			}
+		}
+		if tags != nil {
+			if tagsErr := setTags(tx, itemID, audit.before[itemID].UserId, tags); tagsErr != nil {
+				return tagsErr
+			}
+		}
+		return audit.record(model.HistoryUpdate)
+	})
+}
+
+// CountAll method of ItemsRepository
+// Count the items matching the filter, ignoring the pagination
+// @param userID
+// @param filter
+// @return total
+// @throw error
+func (itemsRepository ItemsRepository) CountAll(userID uint64, filter ItemFilter) (int, error) {
+	where, args := filter.build(userID)
+	var total int
+	countErr := itemsRepository.conn().QueryRow("SELECT COUNT(*) FROM items WHERE "+where, args...).Scan(&total)
+	return total, countErr
+}
+
 // Update method of ItemsRepository
+// Omitted tags are kept, an empty list removes all the tags
 // @param item
 // @param itemInput
 // @throw error
 func (itemsRepository ItemsRepository) Update(item *model.Item, itemInput *model.ItemInput) error {
-	_, updatedError := itemsRepository.Db.Exec(
-		"UPDATE items set title = ?, description = ?, status = ? WHERE item_id = ?",
-		itemInput.Title,
-		itemInput.Description,
-		itemInput.Status,
+	return itemsRepository.update(item, itemInput, model.HistoryUpdate)
+}
+
+// Revert method of ItemsRepository
+// Update the item to the state of a previous version, recorded as a revert
+// @param item
+// @param itemInput state of the version
+// @throw error
+func (itemsRepository ItemsRepository) Revert(item *model.Item, itemInput *model.ItemInput) error {
+	return itemsRepository.update(item, itemInput, model.HistoryRevert)
+}
+
+// update replace the fields of the item and record the change with the action
+func (itemsRepository ItemsRepository) update(item *model.Item, itemInput *model.ItemInput, action string) error {
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		audit, auditErr := itemsRepository.audit(tx, item.ItemId)
+		if auditErr != nil {
+			return auditErr
+		}
+		if _, updatedError := tx.Exec(
+			"UPDATE items set assignee_id = ?, list_id = ?, parent_id = ?, title = ?, description = ?, status = ?, priority = ?, due_at = ?, start_at = ?, recurrence = ?, "+completedAtAssignment+" WHERE item_id = ?",
+			itemInput.AssigneeId,
+			itemInput.ListId,
+			itemInput.ParentId,
+			itemInput.Title,
+			itemInput.Description,
+			itemInput.Status,
+			itemInput.Priority,
+			itemInput.DueAt,
+			itemInput.StartAt,
+			itemInput.Recurrence,
+			model.StatusCompleted,
+			item.ItemId,
+		); updatedError != nil {
+			return updatedError
+		}
+		if itemInput.Tags != nil {
+			if tagsErr := setTags(tx, item.ItemId, item.UserId, itemInput.Tags); tagsErr != nil {
+				return tagsErr
+			}
+		}
+		return audit.record(action)
+	})
+}
+
+// setTags replace the tags of the item in the transaction, create the missing tags of the user
+func setTags(tx *sql.Tx, itemID, userID uint64, tags []string) error {
+	for _, tag := range tags {
+		if _, insertErr := tx.Exec(
+			"INSERT INTO tags (user_id, name) values (?, ?) ON DUPLICATE KEY UPDATE tag_id = tag_id",
+			userID,
+			tag,
+		); insertErr != nil {
+			return insertErr
+		}
+	}
+	if _, deletedErr := tx.Exec("DELETE FROM item_tags WHERE item_id = ?", itemID); deletedErr != nil {
+		return deletedErr
+	}
+	if len(tags) > 0 {
+		args := []any{itemID, userID}
+		for _, tag := range tags {
+			args = append(args, tag)
+		}
+		if _, insertErr := tx.Exec(
+			"INSERT INTO item_tags (item_id, tag_id) SELECT ?, tag_id FROM tags WHERE user_id = ? and name IN ("+placeholders(len(tags))+")",
+			args...,
+		); insertErr != nil {
+			return insertErr
+		}
+	}
+	return nil
+}
+
+// InsertNextOccurrence method of ItemsRepository
+// Create the next occurrence of a completed recurring item with the same tags
+// The recurrence moves to the new item so completing the old one again does not repeat it
+// @param completed the completed item
+// @param next the new occurrence, ItemId is set on success
+// @throw error
+func (itemsRepository ItemsRepository) InsertNextOccurrence(completed model.Item, next *model.Item) error {
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		if lockErr := lockPositions(tx, completed.UserId); lockErr != nil {
+			return lockErr
+		}
+		completedAudit, auditErr := itemsRepository.audit(tx, completed.ItemId)
+		if auditErr != nil {
+			return auditErr
+		}
+		following, followingErr := itemsRepository.in(tx).AdjacentPosition(completed.UserId, completed.ItemId, completed.Position, false)
+		if followingErr != nil {
+			return followingErr
+		}
+		position, positionErr := rank.Between(completed.Position, following)
+		if positionErr != nil {
+			return positionErr
+		}
+		next.Position = position
+		nextAudit, auditErr := itemsRepository.audit(tx)
+		if auditErr != nil {
+			return auditErr
+		}
+		result, insertErr := tx.Exec(
+			"INSERT INTO items (user_id, assignee_id, list_id, parent_id, title, description, status, priority, position, due_at, start_at, recurrence) values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			next.UserId,
+			next.AssigneeId,
+			next.ListId,
+			next.ParentId,
+			next.Title,
+			next.Description,
+			next.Status,
+			next.Priority,
+			next.Position,
+			next.DueAt,
+			next.StartAt,
+			next.Recurrence,
+		)
+		if insertErr != nil {
+			return insertErr
+		}
+		lastInsertId, lastInsertErr := result.LastInsertId()
+		if lastInsertErr != nil {
+			return lastInsertErr
+		}
+		if _, tagsErr := tx.Exec(
+			"INSERT INTO item_tags (item_id, tag_id) SELECT ?, tag_id FROM item_tags WHERE item_id = ?",
+			lastInsertId,
+			completed.ItemId,
+		); tagsErr != nil {
+			return tagsErr
+		}
+		if _, updatedErr := tx.Exec(
+			"UPDATE items set recurrence = NULL WHERE item_id = ?",
+			completed.ItemId,
+		); updatedErr != nil {
+			return updatedErr
+		}
+		next.ItemId = uint64(lastInsertId)
+		nextAudit.track(next.ItemId)
+		if recordErr := completedAudit.record(model.HistoryUpdate); recordErr != nil {
+			return recordErr
+		}
+		return nextAudit.record(model.HistoryCreate)
+	})
+}
+
+// lockPositions lock the row of the user until the end of the transaction, before the positions of its items are read
+// The transactions placing an item of the user run one after the other, so the gap locks taken by the reads
+// of the positions never deadlock two concurrent inserts, the reads lock to see the latest positions in any snapshot
+func lockPositions(tx *sql.Tx, userID uint64) error {
+	var locked uint64
+	return tx.QueryRow("SELECT user_id FROM users WHERE user_id = ? FOR UPDATE", userID).Scan(&locked)
+}
+
+// LastPosition method of ItemsRepository
+// Call lockPositions first in a transaction writing the position
+// @param userID
+// @return the greatest position of the items of the user, empty when there is none
+// @throw error
+func (itemsRepository ItemsRepository) LastPosition(userID uint64) (string, error) {
+	var position sql.NullString
+	queryErr := itemsRepository.conn().QueryRow("SELECT MAX(position) FROM items WHERE user_id = ? FOR UPDATE", userID).Scan(&position)
+	if queryErr != nil {
+		return "", queryErr
+	}
+	return position.String, nil
+}
+
+// AdjacentPosition method of ItemsRepository
+// Find the position right before or right after the given one, ignoring the excluded item
+// Call lockPositions first in a transaction writing the position
+// @param userID
+// @param excludeID
+// @param position
+// @param before
+// @return the adjacent position, empty at the start or the end of the order
+// @throw error
+func (itemsRepository ItemsRepository) AdjacentPosition(userID, excludeID uint64, position string, before bool) (string, error) {
+	exec := "SELECT position FROM items WHERE user_id = ? and item_id <> ? and position > ? order by position LIMIT 1 FOR UPDATE"
+	if before {
+		exec = "SELECT position FROM items WHERE user_id = ? and item_id <> ? and position < ? order by position DESC LIMIT 1 FOR UPDATE"
+	}
+	var adjacent string
+	queryErr := itemsRepository.conn().QueryRow(exec, userID, excludeID, position).Scan(&adjacent)
+	if queryErr == sql.ErrNoRows {
+		return "", nil
+	}
+	if queryErr != nil {
+		return "", queryErr
+	}
+	return adjacent, nil
+}
+
+// Move method of ItemsRepository
+// Place the item right before or right after the target, only the row of the moved item is written
+// The positions of the user are locked until the item is moved so concurrent moves get distinct positions, see lockPositions
+// Fail with ErrMoveTargetNotFound when the target is in the trash
+// @param item the moved item, its Position is set on success
+// @param targetID
+// @param before
+// @throw error
+func (itemsRepository ItemsRepository) Move(item *model.Item, targetID uint64, before bool) error {
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		if lockErr := lockPositions(tx, item.UserId); lockErr != nil {
+			return lockErr
+		}
+		audit, auditErr := itemsRepository.audit(tx, item.ItemId)
+		if auditErr != nil {
+			return auditErr
+		}
+		var target string
+		targetErr := tx.QueryRow(
+			"SELECT position FROM items WHERE item_id = ? and user_id = ? and deleted_at IS NULL FOR UPDATE",
+			targetID,
+			item.UserId,
+		).Scan(&target)
+		if targetErr == sql.ErrNoRows {
+			return ErrMoveTargetNotFound
+		}
+		if targetErr != nil {
+			return targetErr
+		}
+		adjacent, adjacentErr := itemsRepository.in(tx).AdjacentPosition(item.UserId, item.ItemId, target, before)
+		if adjacentErr != nil {
+			return adjacentErr
+		}
+		lower, upper := target, adjacent
+		if before {
+			lower, upper = adjacent, target
+		}
+		position, positionErr := rank.Between(lower, upper)
+		if positionErr != nil {
+			return positionErr
+		}
+		if _, updatedErr := tx.Exec("UPDATE items set position = ? WHERE item_id = ?", position, item.ItemId); updatedErr != nil {
+			return updatedErr
+		}
+		item.Position = position
+		return audit.record(model.HistoryMove)
+	})
+}
+
+// FindDescendants method of ItemsRepository
+// Find the children of the item at any depth
+// @param itemID
+// @param userID
+// @return list item
+// @throw error
+func (itemsRepository ItemsRepository) FindDescendants(itemID, userID uint64) ([]model.Item, error) {
+	exec := "WITH RECURSIVE tree (item_id) AS (" +
+		"SELECT item_id FROM items WHERE parent_id = ? and user_id = ?" +
+		" UNION ALL SELECT i.item_id FROM items i JOIN tree ON i.parent_id = tree.item_id" +
+		") SELECT " + itemColumns + " FROM items WHERE item_id IN (SELECT item_id FROM tree) and deleted_at IS NULL order by status, position, item_id"
+
+	return itemsRepository.queryItems(exec, itemID, userID)
+}
+
+// FindVisibleDescendants method of ItemsRepository
+// Find the children of the item at any depth the user can access, see accessCondition
+// A subtask is only visible to the user it is shared with, like with Find, so the subtasks of a shared item may be left out
+// @param itemID
+// @param userID
+// @return list item
+// @throw error
+func (itemsRepository ItemsRepository) FindVisibleDescendants(itemID, userID uint64) ([]model.Item, error) {
+	access, accessArgs := accessCondition(userID)
+	exec := "WITH RECURSIVE tree (item_id) AS (" +
+		"SELECT item_id FROM items WHERE parent_id = ?" +
+		" UNION ALL SELECT i.item_id FROM items i JOIN tree ON i.parent_id = tree.item_id" +
+		") SELECT " + itemColumns + " FROM items WHERE item_id IN (SELECT item_id FROM tree) and deleted_at IS NULL and " + access +
+		" order by status, position, item_id"
+
+	return itemsRepository.queryItems(exec, append([]any{itemID}, accessArgs...)...)
+}
+
+// Delete method of ItemsRepository
+// Delete the item for good, see Trash for the soft delete
+// The children are moved to the parent of the item when reparentChildren is set,
+// otherwise they are deleted at any depth
+// @param item
+// @param reparentChildren
+// @throw error
+func (itemsRepository ItemsRepository) Delete(item model.Item, reparentChildren bool) error {
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		if reparentChildren {
+			if reparentErr := itemsRepository.reparentChildren(tx, item); reparentErr != nil {
+				return reparentErr
+			}
+		}
+		descendants, findErr := descendantIDs(tx, item.ItemId, "TRUE")
+		if findErr != nil {
+			return findErr
+		}
+		audit, auditErr := itemsRepository.audit(tx, append([]uint64{item.ItemId}, descendants...)...)
+		if auditErr != nil {
+			return auditErr
+		}
+		if deletedErr := deleteDescendants(tx, item.ItemId); deletedErr != nil {
+			return deletedErr
+		}
+		if _, deletedErr := tx.Exec(
+			"DELETE FROM items WHERE item_id = ?",
+			item.ItemId,
+		); deletedErr != nil {
+			return deletedErr
+		}
+		return audit.record(model.HistoryDelete)
+	})
+}
+
+// reparentChildren move the children of the item to its parent
+func (itemsRepository ItemsRepository) reparentChildren(tx *sql.Tx, item model.Item) error {
+	children, findErr := descendantIDs(tx, item.ItemId, "parent_id = ?", item.ItemId)
+	if findErr != nil || len(children) == 0 {
+		return findErr
+	}
+	audit, auditErr := itemsRepository.audit(tx, children...)
+	if auditErr != nil {
+		return auditErr
+	}
+	if _, updatedErr := tx.Exec(
+		"UPDATE items set parent_id = ? WHERE parent_id = ?",
+		item.ParentId,
 		item.ItemId,
+	); updatedErr != nil {
+		return updatedErr
+	}
+	return audit.record(model.HistoryUpdate)
+}
+
+// Trash method of ItemsRepository
+// Move the item to the trash, the children are moved to the parent of the item when reparentChildren is set,
+// otherwise they go to the trash with it
+// @param item
+// @param reparentChildren
+// @throw error
+func (itemsRepository ItemsRepository) Trash(item model.Item, reparentChildren bool) error {
+	deletedAt := time.Now().UTC().Format(model.DatabaseTimeLayout)
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		ids := []uint64{item.ItemId}
+		if reparentChildren {
+			if reparentErr := itemsRepository.reparentChildren(tx, item); reparentErr != nil {
+				return reparentErr
+			}
+		} else {
+			descendants, findErr := descendantIDs(tx, item.ItemId, "deleted_at IS NULL")
+			if findErr != nil {
+				return findErr
+			}
+			ids = append(ids, descendants...)
+		}
+		audit, auditErr := itemsRepository.audit(tx, ids...)
+		if auditErr != nil {
+			return auditErr
+		}
+		if _, updatedErr := tx.Exec(
+			"UPDATE items set deleted_at = ? WHERE item_id IN ("+placeholders(len(ids))+")",
+			append([]any{deletedAt}, idArgs(ids)...)...,
+		); updatedErr != nil {
+			return updatedErr
+		}
+		return audit.record(model.HistoryTrash)
+	})
+}
+
+// Restore method of ItemsRepository
+// Take the item out of the trash with the children trashed along with it
+// The item is moved to the top level when its parent is still in the trash
+// @param item
+// @throw error
+func (itemsRepository ItemsRepository) Restore(item model.Item) error {
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		deletedAt := fromRFC3339(item.DeletedAt)
+		descendants, findErr := descendantIDs(tx, item.ItemId, "deleted_at = ?", deletedAt)
+		if findErr != nil {
+			return findErr
+		}
+		ids := append([]uint64{item.ItemId}, descendants...)
+		audit, auditErr := itemsRepository.audit(tx, ids...)
+		if auditErr != nil {
+			return auditErr
+		}
+		if _, updatedErr := tx.Exec(
+			"UPDATE items set deleted_at = NULL WHERE item_id IN ("+placeholders(len(ids))+")",
+			idArgs(ids)...,
+		); updatedErr != nil {
+			return updatedErr
+		}
+		if item.ParentId != nil {
+			var liveParent int
+			if queryErr := tx.QueryRow(
+				"SELECT COUNT(*) FROM items WHERE item_id = ? and deleted_at IS NULL",
+				*item.ParentId,
+			).Scan(&liveParent); queryErr != nil {
+				return queryErr
+			}
+			if liveParent == 0 {
+				if _, updatedErr := tx.Exec("UPDATE items set parent_id = NULL WHERE item_id = ?", item.ItemId); updatedErr != nil {
+					return updatedErr
+				}
+			}
+		}
+		return audit.record(model.HistoryRestore)
+	})
+}
+
+// Archive method of ItemsRepository
+// Archive the item, or take it out of the archive when archived is false
+// @param itemID
+// @param archived
+// @throw error
+func (itemsRepository ItemsRepository) Archive(itemID uint64, archived bool) error {
+	exec, action := "UPDATE items set archived_at = UTC_TIMESTAMP() WHERE item_id = ? and archived_at IS NULL", model.HistoryArchive
+	if !archived {
+		exec, action = "UPDATE items set archived_at = NULL WHERE item_id = ?", model.HistoryUnarchive
+	}
+	return itemsRepository.transaction(func(tx *sql.Tx) error {
+		audit, auditErr := itemsRepository.audit(tx, itemID)
+		if auditErr != nil {
+			return auditErr
+		}
+		if _, updatedErr := tx.Exec(exec, itemID); updatedErr != nil {
+			return updatedErr
+		}
+		return audit.record(action)
+	})
+}
+
+// ArchiveCompleted method of ItemsRepository
+// Archive the items of every user completed before the given date
+// @param before UTC database layout
+// @return number of archived items
+// @throw error
+func (itemsRepository ItemsRepository) ArchiveCompleted(before string) (int, error) {
+	return itemsRepository.eachBatch(
+		"SELECT item_id FROM items WHERE status = ? and completed_at < ? and archived_at IS NULL and deleted_at IS NULL order by item_id LIMIT ?",
+		[]any{model.StatusCompleted, before},
+		func(id uint64) error {
+			return itemsRepository.Archive(id, true)
+		},
 	)
-	return updatedError
 }
 
-// Delete method of ItemsRepository
-// @param itemId
+// PurgeTrash method of ItemsRepository
+// Delete for good the items trashed before the given date, with their children
+// @param before UTC database layout
+// @return number of purged items
 // @throw error
-func (itemsRepository ItemsRepository) Delete(itemId int) error {
-	_, updatedError := itemsRepository.Db.Exec(
-		"DELETE FROM items WHERE item_id = ?",
-		itemId,
+func (itemsRepository ItemsRepository) PurgeTrash(before string) (int, error) {
+	return itemsRepository.eachBatch(
+		"SELECT item_id FROM items WHERE deleted_at < ? order by item_id LIMIT ?",
+		[]any{before},
+		func(id uint64) error {
+			return itemsRepository.Delete(model.Item{ItemId: id}, false)
+		},
+	)
+}
+
+// eachBatch run fn on the IDs selected by the query, by batches of jobBatchSize
+// until the query finds nothing more, fn must make the item leave the selection
+func (itemsRepository ItemsRepository) eachBatch(exec string, args []any, fn func(id uint64) error) (int, error) {
+	done := 0
+	for {
+		rows, err := itemsRepository.conn().Query(exec, append(args, jobBatchSize)...)
+		if err != nil {
+			return done, err
+		}
+		ids := []uint64{}
+		for rows.Next() {
+			var id uint64
+			if scanErr := rows.Scan(&id); scanErr != nil {
+				rows.Close()
+				return done, scanErr
+			}
+			ids = append(ids, id)
+		}
+		rows.Close()
+		if rowsErr := rows.Err(); rowsErr != nil {
+			return done, rowsErr
+		}
+
+		for _, id := range ids {
+			if fnErr := fn(id); fnErr != nil {
+				return done, fnErr
+			}
+			done++
+		}
+		if len(ids) < jobBatchSize {
+			return done, nil
+		}
+	}
+}
+
+// descendantIDs get the IDs of the children of the item at any depth matching the condition
+func descendantIDs(tx *sql.Tx, itemID uint64, condition string, args ...any) ([]uint64, error) {
+	rows, err := tx.Query(
+		"WITH RECURSIVE tree (item_id) AS ("+
+			"SELECT item_id FROM items WHERE parent_id = ?"+
+			" UNION ALL SELECT i.item_id FROM items i JOIN tree ON i.parent_id = tree.item_id"+
+			") SELECT item_id FROM items WHERE item_id IN (SELECT item_id FROM tree) and "+condition,
+		append([]any{itemID}, args...)...,
 	)
-	return updatedError
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := []uint64{}
+	for rows.Next() {
+		var id uint64
+		if scanErr := rows.Scan(&id); scanErr != nil {
+			return nil, scanErr
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// deleteDescendants delete the children of the item, deepest level first
+// so the parent_id foreign key is never violated
+func deleteDescendants(tx *sql.Tx, itemID uint64) error {
+	rows, err := tx.Query(
+		"WITH RECURSIVE tree (item_id, depth) AS ("+
+			"SELECT item_id, 1 FROM items WHERE parent_id = ?"+
+			" UNION ALL SELECT i.item_id, tree.depth + 1 FROM items i JOIN tree ON i.parent_id = tree.item_id"+
+			") SELECT item_id, depth FROM tree order by depth desc",
+		itemID,
+	)
+	if err != nil {
+		return err
+	}
+	levels := [][]any{}
+	currentDepth := 0
+	for rows.Next() {
+		var id uint64
+		var depth int
+		if scanErr := rows.Scan(&id, &depth); scanErr != nil {
+			rows.Close()
+			return scanErr
+		}
+		if depth != currentDepth {
+			levels = append(levels, []any{})
+			currentDepth = depth
+		}
+		levels[len(levels)-1] = append(levels[len(levels)-1], id)
+	}
+	rows.Close()
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return rowsErr
+	}
+
+	for _, level := range levels {
+		if _, deletedErr := tx.Exec(
+			"DELETE FROM items WHERE item_id IN ("+placeholders(len(level))+")",
+			level...,
+		); deletedErr != nil {
+			return deletedErr
+		}
+	}
+	return nil
 }
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+)
+
+type ListsRepository struct {
+	Db *sql.DB
+}
+
+// Insert method of ListsRepository
+// @param list
+// @throw error
+func (listsRepository ListsRepository) Insert(list *model.List) error {
+	result, err := listsRepository.Db.Exec(
+		"INSERT INTO lists (user_id, name) values (?, ?)",
+		list.UserId,
+		list.Name,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	list.ListId = uint64(lastInsertId)
+	return nil
+}
+
+// Find method of ListsRepository
+// Find the list of the user or shared with the user
+// @param id
+// @param userID
+// @return list
+// @throw error
+func (listsRepository ListsRepository) Find(id uint64, userID uint64) (model.List, error) {
+	var list model.List
+	access, args := listAccessCondition(userID)
+	exec := "SELECT list_id, user_id, name, created_at, updated_at FROM lists WHERE list_id = ? and " + access
+	queryErr := listsRepository.Db.QueryRow(exec, append([]any{id}, args...)...).Scan(
+		&list.ListId,
+		&list.UserId,
+		&list.Name,
+		&list.CreatedAt,
+		&list.UpdatedAt,
+	)
+	if queryErr != nil {
+		return model.List{}, queryErr
+	}
+
+	return list, nil
+}
+
+// FindAll method of ListsRepository
+// Get the lists of the user and the lists shared with the user
+// @param userID
+// @return lists
+// @throw error
+func (listsRepository ListsRepository) FindAll(userID uint64) ([]model.List, error) {
+	access, args := listAccessCondition(userID)
+	rows, err := listsRepository.Db.Query(
+		"SELECT list_id, user_id, name, created_at, updated_at FROM lists where "+access+" order by name, list_id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lists := []model.List{}
+	for rows.Next() {
+		var list model.List
+		if scanErr := rows.Scan(
+			&list.ListId,
+			&list.UserId,
+			&list.Name,
+			&list.CreatedAt,
+			&list.UpdatedAt,
+		); scanErr != nil {
+			return nil, scanErr
+		}
+		lists = append(lists, list)
+	}
+
+	return lists, rows.Err()
+}
+
+// Update method of ListsRepository
+// @param list
+// @param listInput
+// @throw error
+func (listsRepository ListsRepository) Update(list *model.List, listInput *model.ListInput) error {
+	_, updatedError := listsRepository.Db.Exec(
+		"UPDATE lists set name = ? WHERE list_id = ?",
+		listInput.Name,
+		list.ListId,
+	)
+	return updatedError
+}
+
+// Delete method of ListsRepository
+// Items of the list are kept and moved out of the list
+// @param listId
+// @throw error
+func (listsRepository ListsRepository) Delete(listId uint64) error {
+	_, deletedError := listsRepository.Db.Exec(
+		"DELETE FROM lists WHERE list_id = ?",
+		listId,
+	)
+	return deletedError
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+)
+
+const loginSessionColumns string = "login_session_id, user_id, family_id, user_agent, ip_address, created_at, last_seen_at"
+
+// LoginSessionLastSeenDelay the last seen time of a session is written at most once in this number of seconds
+const LoginSessionLastSeenDelay int = 60
+
+type LoginSessionsRepository struct {
+	Db *sql.DB
+}
+
+// scanLoginSession scan a row selected with loginSessionColumns into the session
+func scanLoginSession(row rowScanner, loginSession *model.LoginSession) error {
+	return row.Scan(
+		&loginSession.LoginSessionId,
+		&loginSession.UserId,
+		&loginSession.FamilyId,
+		&loginSession.UserAgent,
+		&loginSession.IpAddress,
+		&loginSession.CreatedAt,
+		&loginSession.LastSeenAt,
+	)
+}
+
+// Insert method of LoginSessionsRepository
+// @param loginSession
+// @throw error
+func (loginSessionsRepository LoginSessionsRepository) Insert(loginSession *model.LoginSession) error {
+	result, err := loginSessionsRepository.Db.Exec(
+		"INSERT INTO login_sessions (user_id, family_id, user_agent, ip_address, last_seen_at) values (?, ?, ?, ?, UTC_TIMESTAMP())",
+		loginSession.UserId,
+		loginSession.FamilyId,
+		loginSession.UserAgent,
+		loginSession.IpAddress,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	loginSession.LoginSessionId = uint64(lastInsertId)
+	return nil
+}
+
+// FindAll method of LoginSessionsRepository
+// The sessions are active until they are revoked or their refresh token is expired
+// @param userID
+// @return active sessions of the user, the last seen first
+// @throw error
+func (loginSessionsRepository LoginSessionsRepository) FindAll(userID uint64) ([]model.LoginSession, error) {
+	rows, err := loginSessionsRepository.Db.Query(
+		"SELECT "+loginSessionColumns+" FROM login_sessions WHERE user_id = ? and revoked_at IS NULL"+
+			" and EXISTS (SELECT 1 FROM refresh_tokens WHERE refresh_tokens.family_id = login_sessions.family_id"+
+			" and used_at IS NULL and revoked_at IS NULL and expires_at > UTC_TIMESTAMP())"+
+			" order by last_seen_at DESC, login_session_id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loginSessions := []model.LoginSession{}
+	for rows.Next() {
+		var loginSession model.LoginSession
+		if scanErr := scanLoginSession(rows, &loginSession); scanErr != nil {
+			return nil, scanErr
+		}
+		loginSessions = append(loginSessions, loginSession)
+	}
+	return loginSessions, rows.Err()
+}
+
+// FindByID method of LoginSessionsRepository
+// @param loginSessionID
+// @param userID owner of the session
+// @return session, empty when the user has no such session
+// @throw error
+func (loginSessionsRepository LoginSessionsRepository) FindByID(loginSessionID, userID uint64) (model.LoginSession, error) {
+	var loginSession model.LoginSession
+	row := loginSessionsRepository.Db.QueryRow(
+		"SELECT "+loginSessionColumns+" FROM login_sessions WHERE login_session_id = ? and user_id = ? and revoked_at IS NULL",
+		loginSessionID,
+		userID,
+	)
+	if scanErr := scanLoginSession(row, &loginSession); scanErr != nil && scanErr != sql.ErrNoRows {
+		return model.LoginSession{}, scanErr
+	}
+	return loginSession, nil
+}
+
+// Touch method of LoginSessionsRepository
+// Write the last seen time and IP address of the session, at most once in LoginSessionLastSeenDelay
+// @param familyID
+// @param ipAddress
+// @throw error
+func (loginSessionsRepository LoginSessionsRepository) Touch(familyID, ipAddress string) error {
+	_, updatedErr := loginSessionsRepository.Db.Exec(
+		"UPDATE login_sessions set last_seen_at = UTC_TIMESTAMP(), ip_address = ?"+
+			" WHERE family_id = ? and last_seen_at < UTC_TIMESTAMP() - INTERVAL ? SECOND",
+		ipAddress,
+		familyID,
+		LoginSessionLastSeenDelay,
+	)
+	return updatedErr
+}
+
+// PurgeExpired method of LoginSessionsRepository
+// Delete the sessions whose refresh tokens are all purged, the sessions of the last day are kept for their first token
+// @return number of deleted sessions
+// @throw error
+func (loginSessionsRepository LoginSessionsRepository) PurgeExpired() (int, error) {
+	result, deletedErr := loginSessionsRepository.Db.Exec(
+		"DELETE FROM login_sessions WHERE NOT EXISTS" +
+			" (SELECT 1 FROM refresh_tokens WHERE refresh_tokens.family_id = login_sessions.family_id)" +
+			" and created_at < UTC_TIMESTAMP() - INTERVAL 1 DAY",
+	)
+	if deletedErr != nil {
+		return 0, deletedErr
+	}
+	deleted, affectedErr := result.RowsAffected()
+	return int(deleted), affectedErr
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+)
+
+const notificationColumns string = "notification_id, user_id, item_id, actor_id, type, payload, read_at, created_at"
+
+type NotificationsRepository struct {
+	Db *sql.DB
+}
+
+// notifyAssignee send the assignment events of the changed item, in the transaction of the change
+// The new assignee is told the item is assigned, the previous one that it is not anymore
+// The users are not told about their own changes
+func (audit *itemAudit) notifyAssignee(previous, current model.Item) error {
+	events := []struct {
+		assigneeID       *uint64
+		notificationType string
+	}{
+		{previous.AssigneeId, model.NotificationUnassigned},
+		{current.AssigneeId, model.NotificationAssigned},
+	}
+	for _, event := range events {
+		if event.assigneeID == nil || *event.assigneeID == audit.actor.UserId {
+			continue
+		}
+		notification := model.Notification{
+			UserId: *event.assigneeID,
+			ItemId: &current.ItemId,
+			Type:   event.notificationType,
+			Payload: map[string]any{
+				"item_id":  current.ItemId,
+				"title":    current.Title,
+				"owner_id": current.UserId,
+				"assignee": model.FieldChange{Before: previous.AssigneeId, After: current.AssigneeId},
+			},
+		}
+		if audit.actor.UserId != 0 {
+			notification.ActorId = &audit.actor.UserId
+		}
+		if insertErr := insertNotification(audit.tx, &notification); insertErr != nil {
+			return insertErr
+		}
+	}
+	return nil
+}
+
+// insertNotification write the notification with the executor
+func insertNotification(exec executor, notification *model.Notification) error {
+	payload, payloadErr := json.Marshal(notification.Payload)
+	if payloadErr != nil {
+		return payloadErr
+	}
+	result, err := exec.Exec(
+		"INSERT INTO notifications (user_id, item_id, actor_id, type, payload) values (?, ?, ?, ?, ?)",
+		notification.UserId,
+		notification.ItemId,
+		notification.ActorId,
+		notification.Type,
+		payload,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	notification.NotificationId = uint64(lastInsertId)
+	return nil
+}
+
+// scanNotification scan a row selected with notificationColumns into the notification
+func scanNotification(row rowScanner, notification *model.Notification) error {
+	var itemID, actorID sql.NullInt64
+	var readAt sql.NullString
+	var payload []byte
+	scanErr := row.Scan(
+		&notification.NotificationId,
+		&notification.UserId,
+		&itemID,
+		&actorID,
+		&notification.Type,
+		&payload,
+		&readAt,
+		&notification.CreatedAt,
+	)
+	if scanErr != nil {
+		return scanErr
+	}
+	notification.ItemId = toUint64(itemID)
+	notification.ActorId = toUint64(actorID)
+	notification.ReadAt = toRFC3339(readAt)
+	return json.Unmarshal(payload, &notification.Payload)
+}
+
+// FindAll method of NotificationsRepository
+// @param userID
+// @param unread only the notifications not read yet
+// @param limit
+// @param offset
+// @return notifications, the latest first
+// @throw error
+func (notificationsRepository NotificationsRepository) FindAll(userID uint64, unread bool, limit, offset int) ([]model.Notification, error) {
+	exec := "SELECT " + notificationColumns + " FROM notifications WHERE user_id = ?"
+	if unread {
+		exec += " and read_at IS NULL"
+	}
+	rows, err := notificationsRepository.Db.Query(
+		exec+" order by notification_id DESC LIMIT ? OFFSET ?",
+		userID,
+		limit,
+		offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []model.Notification{}
+	for rows.Next() {
+		var notification model.Notification
+		if scanErr := scanNotification(rows, &notification); scanErr != nil {
+			return nil, scanErr
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, rows.Err()
+}
+
+// MarkRead method of NotificationsRepository
+// Mark the notification of the user as read, every unread notification of the user when id is 0
+// @param id
+// @param userID
+// @throw error
+func (notificationsRepository NotificationsRepository) MarkRead(id, userID uint64) error {
+	exec, args := "UPDATE notifications set read_at = UTC_TIMESTAMP() WHERE user_id = ? and read_at IS NULL", []any{userID}
+	if id != 0 {
+		exec, args = exec+" and notification_id = ?", append(args, id)
+	}
+	_, updatedErr := notificationsRepository.Db.Exec(exec, args...)
+	return updatedErr
+}
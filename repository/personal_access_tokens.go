@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"strings"
+)
+
+const personalAccessTokenColumns string = "personal_access_token_id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at"
+
+// PersonalAccessTokenLastUsedDelay the last used time of a token is written at most once in this number of seconds
+const PersonalAccessTokenLastUsedDelay int = 60
+
+type PersonalAccessTokensRepository struct {
+	Db *sql.DB
+}
+
+// scanPersonalAccessToken scan a row selected with personalAccessTokenColumns into the token
+func scanPersonalAccessToken(row rowScanner, token *model.PersonalAccessToken) error {
+	var scopes string
+	var expiresAt, lastUsedAt sql.NullString
+	scanErr := row.Scan(
+		&token.PersonalAccessTokenId,
+		&token.UserId,
+		&token.Name,
+		&token.TokenHash,
+		&scopes,
+		&expiresAt,
+		&lastUsedAt,
+		&token.CreatedAt,
+	)
+	if scanErr != nil {
+		return scanErr
+	}
+	token.Scopes = strings.Split(scopes, ",")
+	token.ExpiresAt = toRFC3339(expiresAt)
+	token.LastUsedAt = toRFC3339(lastUsedAt)
+	return nil
+}
+
+// Insert method of PersonalAccessTokensRepository
+// @param token ExpiresAt uses the UTC database layout
+// @throw error
+func (personalAccessTokensRepository PersonalAccessTokensRepository) Insert(token *model.PersonalAccessToken) error {
+	result, err := personalAccessTokensRepository.Db.Exec(
+		"INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, expires_at) values (?, ?, ?, ?, ?)",
+		token.UserId,
+		token.Name,
+		token.TokenHash,
+		strings.Join(token.Scopes, ","),
+		token.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	token.PersonalAccessTokenId = uint64(lastInsertId)
+	return nil
+}
+
+// FindAll method of PersonalAccessTokensRepository
+// The expired tokens are listed too, the revoked tokens are not
+// @param userID
+// @return tokens of the user, the latest first
+// @throw error
+func (personalAccessTokensRepository PersonalAccessTokensRepository) FindAll(userID uint64) ([]model.PersonalAccessToken, error) {
+	rows, err := personalAccessTokensRepository.Db.Query(
+		"SELECT "+personalAccessTokenColumns+" FROM personal_access_tokens WHERE user_id = ? and revoked_at IS NULL"+
+			" order by personal_access_token_id DESC",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []model.PersonalAccessToken{}
+	for rows.Next() {
+		var token model.PersonalAccessToken
+		if scanErr := scanPersonalAccessToken(rows, &token); scanErr != nil {
+			return nil, scanErr
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, rows.Err()
+}
+
+// FindByHash method of PersonalAccessTokensRepository
+// The expired tokens are found too, the revoked tokens are not
+// @param tokenHash
+// @return token, empty when there is no such token
+// @throw error
+func (personalAccessTokensRepository PersonalAccessTokensRepository) FindByHash(tokenHash string) (model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	row := personalAccessTokensRepository.Db.QueryRow(
+		"SELECT "+personalAccessTokenColumns+" FROM personal_access_tokens WHERE token_hash = ? and revoked_at IS NULL",
+		tokenHash,
+	)
+	if scanErr := scanPersonalAccessToken(row, &token); scanErr != nil && scanErr != sql.ErrNoRows {
+		return model.PersonalAccessToken{}, scanErr
+	}
+	return token, nil
+}
+
+// Revoke method of PersonalAccessTokensRepository
+// @param tokenID
+// @param userID owner of the token
+// @return false when the user has no such token
+// @throw error
+func (personalAccessTokensRepository PersonalAccessTokensRepository) Revoke(tokenID, userID uint64) (bool, error) {
+	result, updatedErr := personalAccessTokensRepository.Db.Exec(
+		"UPDATE personal_access_tokens set revoked_at = UTC_TIMESTAMP() WHERE personal_access_token_id = ? and user_id = ? and revoked_at IS NULL",
+		tokenID,
+		userID,
+	)
+	if updatedErr != nil {
+		return false, updatedErr
+	}
+	affected, affectedErr := result.RowsAffected()
+	return affected > 0, affectedErr
+}
+
+// Touch method of PersonalAccessTokensRepository
+// Write the last used time of the token, at most once in PersonalAccessTokenLastUsedDelay
+// @param tokenID
+// @throw error
+func (personalAccessTokensRepository PersonalAccessTokensRepository) Touch(tokenID uint64) error {
+	_, updatedErr := personalAccessTokensRepository.Db.Exec(
+		"UPDATE personal_access_tokens set last_used_at = UTC_TIMESTAMP()"+
+			" WHERE personal_access_token_id = ? and (last_used_at IS NULL or last_used_at < UTC_TIMESTAMP() - INTERVAL ? SECOND)",
+		tokenID,
+		PersonalAccessTokenLastUsedDelay,
+	)
+	return updatedErr
+}
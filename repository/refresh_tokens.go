@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+)
+
+const refreshTokenColumns string = "refresh_token_id, user_id, family_id, token_hash, expires_at, used_at, revoked_at, created_at"
+
+// ErrRefreshTokenReused returned when a refresh token is rotated after it was used or revoked
+var ErrRefreshTokenReused = errors.New("the refresh token was already used")
+
+type RefreshTokensRepository struct {
+	Db *sql.DB
+}
+
+// insertRefreshToken write the refresh token with the executor
+func insertRefreshToken(exec executor, token *model.RefreshToken) error {
+	result, err := exec.Exec(
+		"INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at) values (?, ?, ?, ?)",
+		token.UserId,
+		token.FamilyId,
+		token.TokenHash,
+		token.ExpiresAt,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	token.RefreshTokenId = uint64(lastInsertId)
+	return nil
+}
+
+// Insert method of RefreshTokensRepository
+// @param token ExpiresAt uses the UTC database layout
+// @throw error
+func (refreshTokensRepository RefreshTokensRepository) Insert(token *model.RefreshToken) error {
+	return insertRefreshToken(refreshTokensRepository.Db, token)
+}
+
+// FindByHash method of RefreshTokensRepository
+// The used, revoked and expired tokens are found too
+// @param tokenHash
+// @return refresh token
+// @throw error
+func (refreshTokensRepository RefreshTokensRepository) FindByHash(tokenHash string) (model.RefreshToken, error) {
+	var token model.RefreshToken
+	var expiresAt, usedAt, revokedAt sql.NullString
+	queryErr := refreshTokensRepository.Db.QueryRow(
+		"SELECT "+refreshTokenColumns+" FROM refresh_tokens WHERE token_hash = ?",
+		tokenHash,
+	).Scan(
+		&token.RefreshTokenId,
+		&token.UserId,
+		&token.FamilyId,
+		&token.TokenHash,
+		&expiresAt,
+		&usedAt,
+		&revokedAt,
+		&token.CreatedAt,
+	)
+	if queryErr != nil {
+		return model.RefreshToken{}, queryErr
+	}
+	if converted := toRFC3339(expiresAt); converted != nil {
+		token.ExpiresAt = *converted
+	}
+	token.UsedAt = toRFC3339(usedAt)
+	token.RevokedAt = toRFC3339(revokedAt)
+	return token, nil
+}
+
+// Rotate method of RefreshTokensRepository
+// Mark the token as used and insert its successor, in one transaction
+// A token has at most one successor, the second use fails with ErrRefreshTokenReused
+// Fail with ErrRefreshTokenReused when the token was used or revoked in the meantime
+// @param used
+// @param next successor of the token, in the same family
+// @throw error
+func (refreshTokensRepository RefreshTokensRepository) Rotate(used model.RefreshToken, next *model.RefreshToken) error {
+	tx, txErr := refreshTokensRepository.Db.Begin()
+	if txErr != nil {
+		return txErr
+	}
+	defer tx.Rollback()
+
+	result, updatedErr := tx.Exec(
+		"UPDATE refresh_tokens set used_at = UTC_TIMESTAMP() WHERE refresh_token_id = ? and used_at IS NULL and revoked_at IS NULL",
+		used.RefreshTokenId,
+	)
+	if updatedErr != nil {
+		return updatedErr
+	}
+	affected, affectedErr := result.RowsAffected()
+	if affectedErr != nil {
+		return affectedErr
+	}
+	if affected == 0 {
+		return ErrRefreshTokenReused
+	}
+	if insertErr := insertRefreshToken(tx, next); insertErr != nil {
+		return insertErr
+	}
+	return tx.Commit()
+}
+
+// RevokeFamily method of RefreshTokensRepository
+// Revoke the token and all the tokens rotated from the same login, with the login session
+// @param familyID
+// @throw error
+func (refreshTokensRepository RefreshTokensRepository) RevokeFamily(familyID string) error {
+	return refreshTokensRepository.revoke("family_id = ?", familyID)
+}
+
+// RevokeUser method of RefreshTokensRepository
+// Revoke all the refresh tokens and login sessions of the user
+// @param userID
+// @throw error
+func (refreshTokensRepository RefreshTokensRepository) RevokeUser(userID uint64) error {
+	return refreshTokensRepository.revoke("user_id = ?", userID)
+}
+
+// revoke revoke the refresh tokens and the login sessions matching the condition, in one transaction
+// The access tokens of the revoked login sessions are refused too
+func (refreshTokensRepository RefreshTokensRepository) revoke(condition string, arg any) error {
+	tx, txErr := refreshTokensRepository.Db.Begin()
+	if txErr != nil {
+		return txErr
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"refresh_tokens", "login_sessions"} {
+		_, updatedErr := tx.Exec(
+			"UPDATE "+table+" set revoked_at = UTC_TIMESTAMP() WHERE "+condition+" and revoked_at IS NULL",
+			arg,
+		)
+		if updatedErr != nil {
+			return updatedErr
+		}
+	}
+	return tx.Commit()
+}
+
+// PurgeExpired method of RefreshTokensRepository
+// @return number of deleted tokens
+// @throw error
+func (refreshTokensRepository RefreshTokensRepository) PurgeExpired() (int, error) {
+	result, deletedErr := refreshTokensRepository.Db.Exec("DELETE FROM refresh_tokens WHERE expires_at < UTC_TIMESTAMP()")
+	if deletedErr != nil {
+		return 0, deletedErr
+	}
+	deleted, affectedErr := result.RowsAffected()
+	return int(deleted), affectedErr
+}
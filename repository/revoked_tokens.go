@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"time"
+)
+
+// TokenRevocationsRepository keep the access tokens revoked before their expiry, see auth.RevocationStore
+type TokenRevocationsRepository struct {
+	Db *sql.DB
+}
+
+// Revoked method of TokenRevocationsRepository
+// The token is revoked when its jti or its login session was revoked,
+// or its version is older than the token version of the user
+// @param tokenID jti claim of the token
+// @param sessionID sid claim of the token, the family of its login session
+// @param userID
+// @param version ver claim of the token
+// @return true when the token is revoked
+// @throw error
+func (tokenRevocationsRepository TokenRevocationsRepository) Revoked(tokenID, sessionID string, userID uint64, version int) (bool, error) {
+	var valid bool
+	queryErr := tokenRevocationsRepository.Db.QueryRow(
+		"SELECT token_version = ? and NOT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = ?)"+
+			" and NOT EXISTS (SELECT 1 FROM login_sessions WHERE family_id = ? and revoked_at IS NOT NULL)"+
+			" FROM users WHERE user_id = ?",
+		version,
+		tokenID,
+		sessionID,
+		userID,
+	).Scan(&valid)
+	if queryErr == sql.ErrNoRows {
+		return true, nil
+	}
+	if queryErr != nil {
+		return false, queryErr
+	}
+	return !valid, nil
+}
+
+// Revoke method of TokenRevocationsRepository
+// @param tokenID jti claim of the token
+// @param userID
+// @param expiresAt expiry of the token, the revocation is kept until then
+// @throw error
+func (tokenRevocationsRepository TokenRevocationsRepository) Revoke(tokenID string, userID uint64, expiresAt time.Time) error {
+	_, insertErr := tokenRevocationsRepository.Db.Exec(
+		"INSERT IGNORE INTO revoked_tokens (jti, user_id, expires_at) values (?, ?, ?)",
+		tokenID,
+		userID,
+		expiresAt.UTC().Format(model.DatabaseTimeLayout),
+	)
+	return insertErr
+}
+
+// PurgeExpired method of TokenRevocationsRepository
+// The expired tokens are refused anyway
+// @return number of deleted revocations
+// @throw error
+func (tokenRevocationsRepository TokenRevocationsRepository) PurgeExpired() (int, error) {
+	result, deletedErr := tokenRevocationsRepository.Db.Exec("DELETE FROM revoked_tokens WHERE expires_at < UTC_TIMESTAMP()")
+	if deletedErr != nil {
+		return 0, deletedErr
+	}
+	deleted, affectedErr := result.RowsAffected()
+	return int(deleted), affectedErr
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"strings"
+	"unicode"
+)
+
+const EmptySearchError string = "the search needs at least one word or phrase"
+
+// SearchQuery parsed search query
+type SearchQuery struct {
+	Words    []string
+	Phrases  []string
+	Excludes []string
+	Status   int
+}
+
+// ParseSearchQuery parse the search syntax:
+// words, "quoted phrases", -excluded words and status:done or status:todo
+func ParseSearchQuery(value string) (SearchQuery, error) {
+	var query SearchQuery
+	for _, token := range tokenize(value) {
+		if token.quoted {
+			if phrase := cleanSearchTerm(token.text, true); phrase != "" {
+				query.Phrases = append(query.Phrases, phrase)
+			}
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(token.text), "status:") {
+			status, known := model.StatusNames[strings.ToLower(token.text[len("status:"):])]
+			if !known {
+				return SearchQuery{}, fmt.Errorf("unknown status in %q, use status:done or status:todo", token.text)
+			}
+			query.Status = status
+			continue
+		}
+		if strings.HasPrefix(token.text, "-") {
+			if word := cleanSearchTerm(token.text[1:], false); word != "" {
+				query.Excludes = append(query.Excludes, word)
+			}
+			continue
+		}
+		if word := cleanSearchTerm(token.text, false); word != "" {
+			query.Words = append(query.Words, word)
+		}
+	}
+	if len(query.Words) == 0 && len(query.Phrases) == 0 {
+		return SearchQuery{}, errors.New(EmptySearchError)
+	}
+	return query, nil
+}
+
+// BooleanExpression build the MATCH ... AGAINST expression in boolean mode
+// Every word and phrase is required, words also match as prefix
+func (query SearchQuery) BooleanExpression() string {
+	parts := []string{}
+	for _, word := range query.Words {
+		parts = append(parts, "+"+word+"*")
+	}
+	for _, phrase := range query.Phrases {
+		parts = append(parts, `+"`+phrase+`"`)
+	}
+	for _, exclude := range query.Excludes {
+		parts = append(parts, "-"+exclude)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Terms words and phrases to highlight
+func (query SearchQuery) Terms() []string {
+	return append(append([]string{}, query.Phrases...), query.Words...)
+}
+
+type searchToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenize split the query on spaces, keeping the quoted phrases together
+func tokenize(value string) []searchToken {
+	tokens := []searchToken{}
+	current := strings.Builder{}
+	inQuote := false
+	flush := func(quoted bool) {
+		if current.Len() > 0 {
+			tokens = append(tokens, searchToken{text: current.String(), quoted: quoted})
+			current.Reset()
+		}
+	}
+	for _, r := range value {
+		switch {
+		case r == '"':
+			flush(inQuote)
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			flush(false)
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush(inQuote)
+	return tokens
+}
+
+// cleanSearchTerm remove the boolean mode operators from the term
+// Spaces are kept inside the phrases only
+func cleanSearchTerm(term string, phrase bool) string {
+	cleaned := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '_' {
+			return r
+		}
+		if phrase && unicode.IsSpace(r) {
+			return ' '
+		}
+		return -1
+	}, term)
+	return strings.Join(strings.Fields(cleaned), " ")
+}
+
+// Search method of ItemsRepository
+// Find the items matching the query, best matches first
+// @param limit
+// @param offset
+// @param userID
+// @param query
+// @return list search result
+// @throw error
+func (itemsRepository ItemsRepository) Search(limit, offset int, userID uint64, query SearchQuery) ([]model.SearchResult, error) {
+	expression := query.BooleanExpression()
+	access, accessArgs := accessCondition(userID)
+	exec := "SELECT " + itemColumns + ", MATCH (title, description) AGAINST (? IN BOOLEAN MODE) AS score" +
+		" FROM items WHERE " + access + " and deleted_at IS NULL and archived_at IS NULL and MATCH (title, description) AGAINST (? IN BOOLEAN MODE)"
+	args := append(append([]any{expression}, accessArgs...), expression)
+	if query.Status != 0 {
+		exec += " and status = ?"
+		args = append(args, query.Status)
+	}
+	exec += " order by score DESC, item_id LIMIT ? OFFSET ?"
+	args = append(args, limit, offset)
+
+	rows, err := itemsRepository.conn().Query(exec, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := []model.SearchResult{}
+	for rows.Next() {
+		var result model.SearchResult
+		if scanErr := scanItem(scoredRow{rows, &result.Score}, &result.Item); scanErr != nil {
+			return nil, scanErr
+		}
+		results = append(results, result)
+	}
+	if rowsErr := rows.Err(); rowsErr != nil {
+		return nil, rowsErr
+	}
+
+	items := make([]model.Item, len(results))
+	for i := range results {
+		items[i] = results[i].Item
+	}
+	if tagsErr := itemsRepository.attachTags(items); tagsErr != nil {
+		return nil, tagsErr
+	}
+	for i := range results {
+		results[i].Item = items[i]
+	}
+	return results, nil
+}
+
+// scoredRow scan the item columns followed by the score column
+type scoredRow struct {
+	row   rowScanner
+	score *float64
+}
+
+func (scored scoredRow) Scan(dest ...any) error {
+	return scored.row.Scan(append(dest, scored.score)...)
+}
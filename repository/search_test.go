@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"reflect"
+	"testing"
+)
+
+func TestParseSearchQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    SearchQuery
+		wantErr bool
+	}{
+		{name: "words", value: "buy milk", want: SearchQuery{Words: []string{"buy", "milk"}}},
+		{name: "phrase", value: `"weekly report" draft`, want: SearchQuery{Words: []string{"draft"}, Phrases: []string{"weekly report"}}},
+		{name: "unclosed phrase", value: `"weekly report`, want: SearchQuery{Phrases: []string{"weekly report"}}},
+		{name: "excluded word", value: "report -draft", want: SearchQuery{Words: []string{"report"}, Excludes: []string{"draft"}}},
+		{name: "status done", value: "report status:done", want: SearchQuery{Words: []string{"report"}, Status: model.StatusCompleted}},
+		{name: "status todo", value: "STATUS:Todo report", want: SearchQuery{Words: []string{"report"}, Status: model.StatusProcessing}},
+		{name: "operators removed", value: `+report* (draft) ~"a > b"`, want: SearchQuery{Words: []string{"report", "draft"}, Phrases: []string{"a b"}}},
+		{name: "extra spaces", value: "  report \t draft  ", want: SearchQuery{Words: []string{"report", "draft"}}},
+		{name: "unicode", value: "café naïve_2", want: SearchQuery{Words: []string{"café", "naïve_2"}}},
+		{name: "unknown status", value: "report status:later", wantErr: true},
+		{name: "empty", value: "   ", wantErr: true},
+		{name: "only excluded words", value: "-draft -old", wantErr: true},
+		{name: "only operators", value: `+* "()"`, wantErr: true},
+		{name: "only status", value: "status:done", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseSearchQuery(test.value)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSearchQuery(%q) = %+v, want an error", test.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSearchQuery(%q): %v", test.value, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("ParseSearchQuery(%q) = %+v, want %+v", test.value, got, test.want)
+			}
+		})
+	}
+}
+
+func TestBooleanExpression(t *testing.T) {
+	tests := []struct {
+		name  string
+		query SearchQuery
+		want  string
+	}{
+		{name: "words match as prefix", query: SearchQuery{Words: []string{"buy", "milk"}}, want: "+buy* +milk*"},
+		{name: "phrase", query: SearchQuery{Phrases: []string{"weekly report"}}, want: `+"weekly report"`},
+		{name: "all parts", query: SearchQuery{Words: []string{"report"}, Phrases: []string{"q3 numbers"}, Excludes: []string{"draft"}}, want: `+report* +"q3 numbers" -draft`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.query.BooleanExpression(); got != test.want {
+				t.Fatalf("BooleanExpression() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsedQueryBuildsSafeExpression(t *testing.T) {
+	query, err := ParseSearchQuery(`report" -"draft @>(<) -old*`)
+	if err != nil {
+		t.Fatalf("ParseSearchQuery: %v", err)
+	}
+	want := "+report* +draft* -old"
+	if got := query.BooleanExpression(); got != want {
+		t.Fatalf("BooleanExpression() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"database/sql"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+)
+
+const shareColumns string = "s.share_id, s.item_id, s.list_id, COALESCE(i.title, l.name, ''), s.owner_id, o.username, s.grantee_id, u.username, s.role, s.invited_by, s.accepted_at, s.created_at, s.updated_at"
+
+// shareJoins join the owner, the grantee and the item or the list of the share
+const shareJoins string = " FROM shares s JOIN users o ON o.user_id = s.owner_id JOIN users u ON u.user_id = s.grantee_id" +
+	" LEFT JOIN items i ON i.item_id = s.item_id LEFT JOIN lists l ON l.list_id = s.list_id"
+
+type SharesRepository struct {
+	Db *sql.DB
+}
+
+// accessCondition match the items of the user, the items assigned to the user
+// and the items shared with the user, directly or by their list
+// Only the accepted grants give access
+func accessCondition(userID uint64) (string, []any) {
+	return "(user_id = ? or assignee_id = ?" +
+			" or item_id IN (SELECT item_id FROM shares WHERE grantee_id = ? and accepted_at IS NOT NULL and item_id IS NOT NULL)" +
+			" or list_id IN (SELECT list_id FROM shares WHERE grantee_id = ? and accepted_at IS NOT NULL and list_id IS NOT NULL))",
+		[]any{userID, userID, userID, userID}
+}
+
+// listAccessCondition match the lists of the user and the lists shared with the user
+func listAccessCondition(userID uint64) (string, []any) {
+	return "(user_id = ? or list_id IN (SELECT list_id FROM shares WHERE grantee_id = ? and accepted_at IS NOT NULL and list_id IS NOT NULL))",
+		[]any{userID, userID}
+}
+
+// scanShare scan a row selected with shareColumns into the share
+func scanShare(row rowScanner, share *model.Share) error {
+	var itemID, listID sql.NullInt64
+	var acceptedAt sql.NullString
+	scanErr := row.Scan(
+		&share.ShareId,
+		&itemID,
+		&listID,
+		&share.Name,
+		&share.OwnerId,
+		&share.OwnerUsername,
+		&share.GranteeId,
+		&share.GranteeUsername,
+		&share.Role,
+		&share.InvitedBy,
+		&acceptedAt,
+		&share.CreatedAt,
+		&share.UpdatedAt,
+	)
+	if scanErr != nil {
+		return scanErr
+	}
+	share.ItemId = toUint64(itemID)
+	share.ListId = toUint64(listID)
+	share.AcceptedAt = toRFC3339(acceptedAt)
+	return nil
+}
+
+// Insert method of SharesRepository
+// Inviting the user again only changes the role of the grant, an accepted grant stays accepted
+// @param share
+// @throw error
+func (sharesRepository SharesRepository) Insert(share *model.Share) error {
+	result, err := sharesRepository.Db.Exec(
+		"INSERT INTO shares (item_id, list_id, owner_id, grantee_id, role, invited_by) values (?, ?, ?, ?, ?, ?)"+
+			" ON DUPLICATE KEY UPDATE role = VALUES(role), invited_by = VALUES(invited_by), share_id = LAST_INSERT_ID(share_id)",
+		share.ItemId,
+		share.ListId,
+		share.OwnerId,
+		share.GranteeId,
+		share.Role,
+		share.InvitedBy,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	share.ShareId = uint64(lastInsertId)
+	return nil
+}
+
+// Find method of SharesRepository
+// @param id
+// @return share
+// @throw error
+func (sharesRepository SharesRepository) Find(id uint64) (model.Share, error) {
+	var share model.Share
+	exec := "SELECT " + shareColumns + shareJoins + " WHERE s.share_id = ?"
+	if queryErr := scanShare(sharesRepository.Db.QueryRow(exec, id), &share); queryErr != nil {
+		return model.Share{}, queryErr
+	}
+	return share, nil
+}
+
+// FindByItem method of SharesRepository
+// Get the grants on the item, accepted or not
+// @param itemID
+// @return shares
+// @throw error
+func (sharesRepository SharesRepository) FindByItem(itemID uint64) ([]model.Share, error) {
+	return sharesRepository.findWhere("s.item_id = ?", itemID)
+}
+
+// FindByList method of SharesRepository
+// Get the grants on the list, accepted or not
+// @param listID
+// @return shares
+// @throw error
+func (sharesRepository SharesRepository) FindByList(listID uint64) ([]model.Share, error) {
+	return sharesRepository.findWhere("s.list_id = ?", listID)
+}
+
+// FindInvitations method of SharesRepository
+// Get the grants offered to the user and not accepted yet
+// @param userID
+// @return shares
+// @throw error
+func (sharesRepository SharesRepository) FindInvitations(userID uint64) ([]model.Share, error) {
+	return sharesRepository.findWhere("s.grantee_id = ? and s.accepted_at IS NULL", userID)
+}
+
+// findWhere get the shares matching the condition, the oldest first
+func (sharesRepository SharesRepository) findWhere(condition string, args ...any) ([]model.Share, error) {
+	rows, err := sharesRepository.Db.Query(
+		"SELECT "+shareColumns+shareJoins+" WHERE "+condition+" order by s.share_id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []model.Share{}
+	for rows.Next() {
+		var share model.Share
+		if scanErr := scanShare(rows, &share); scanErr != nil {
+			return nil, scanErr
+		}
+		shares = append(shares, share)
+	}
+
+	return shares, rows.Err()
+}
+
+// Accept method of SharesRepository
+// @param id
+// @throw error
+func (sharesRepository SharesRepository) Accept(id uint64) error {
+	_, updatedError := sharesRepository.Db.Exec(
+		"UPDATE shares set accepted_at = UTC_TIMESTAMP() WHERE share_id = ? and accepted_at IS NULL",
+		id,
+	)
+	return updatedError
+}
+
+// Delete method of SharesRepository
+// @param id
+// @throw error
+func (sharesRepository SharesRepository) Delete(id uint64) error {
+	_, deletedError := sharesRepository.Db.Exec(
+		"DELETE FROM shares WHERE share_id = ?",
+		id,
+	)
+	return deletedError
+}
+
+// Role method of SharesRepository
+// Get the role of the user on the item, owner for the owner of the item,
+// otherwise the highest role accepted on the item or on its list, at least editor for the assignee,
+// empty without access
+// @param item
+// @param userID
+// @return role
+// @throw error
+func (sharesRepository SharesRepository) Role(item model.Item, userID uint64) (string, error) {
+	if item.UserId == userID {
+		return model.RoleOwner, nil
+	}
+	role, roleErr := sharesRepository.grantedRole(
+		"grantee_id = ? and accepted_at IS NOT NULL and (item_id = ? or list_id = ?)",
+		userID,
+		item.ItemId,
+		item.ListId,
+	)
+	if item.AssigneeId != nil && *item.AssigneeId == userID {
+		return model.HighestRole(role, model.RoleEditor), roleErr
+	}
+	return role, roleErr
+}
+
+// ListRole method of SharesRepository
+// Get the role of the user on the list, owner for the owner of the list,
+// otherwise the role accepted on the list, empty without access
+// @param list
+// @param userID
+// @return role
+// @throw error
+func (sharesRepository SharesRepository) ListRole(list model.List, userID uint64) (string, error) {
+	if list.UserId == userID {
+		return model.RoleOwner, nil
+	}
+	return sharesRepository.grantedRole(
+		"grantee_id = ? and accepted_at IS NOT NULL and list_id = ?",
+		userID,
+		list.ListId,
+	)
+}
+
+// grantedRole get the highest role of the grants matching the condition
+func (sharesRepository SharesRepository) grantedRole(condition string, args ...any) (string, error) {
+	rows, err := sharesRepository.Db.Query("SELECT role FROM shares WHERE "+condition, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if scanErr := rows.Scan(&role); scanErr != nil {
+			return "", scanErr
+		}
+		roles = append(roles, role)
+	}
+	return model.HighestRole(roles...), rows.Err()
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"strings"
+	"time"
+)
+
+// defaultSort order of the items when no sort is requested
+var defaultSort = []SortKey{{Column: "status"}, {Column: "position"}, {Column: "item_id"}}
+
+// sortableColumns whitelist of the sort keys, mapped to their column
+// Only these columns ever reach the ORDER BY clause
+var sortableColumns = map[string]string{
+	"item_id":      "item_id",
+	"title":        "title",
+	"status":       "status",
+	"priority":     "priority",
+	"position":     "position",
+	"due_at":       "due_at",
+	"start_at":     "start_at",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+	"completed_at": "completed_at",
+	"archived_at":  "archived_at",
+	"deleted_at":   "deleted_at",
+}
+
+// nullableColumns columns sorted with their NULL values last in both directions
+var nullableColumns = map[string]bool{
+	"due_at":       true,
+	"start_at":     true,
+	"completed_at": true,
+	"archived_at":  true,
+	"deleted_at":   true,
+}
+
+// SortKey a whitelisted sort column with its direction
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// Keyset position of a row in the sort order, used instead of OFFSET
+// Values are the values of the EffectiveSort keys of the row
+type Keyset struct {
+	Values   []any
+	Backward bool
+}
+
+// sortTerm one expression of the ORDER BY clause
+type sortTerm struct {
+	expr string
+	desc bool
+	// index of the key in the effective sort, the value of the term is read from it
+	key int
+	// isNull term sorting the NULL values of the key last
+	isNull bool
+}
+
+// ParseSort parse a sort parameter like "priority,-due_at,created_at"
+// A leading "-" sorts descending, unknown keys are rejected
+func ParseSort(value string) ([]SortKey, error) {
+	keys := []SortKey{}
+	if strings.TrimSpace(value) == "" {
+		return keys, nil
+	}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		desc := strings.HasPrefix(part, "-")
+		name := strings.TrimPrefix(part, "-")
+		column, allowed := sortableColumns[name]
+		if !allowed {
+			return nil, fmt.Errorf("can not sort by %q", name)
+		}
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+		keys = append(keys, SortKey{Column: column, Desc: desc})
+	}
+	return keys, nil
+}
+
+// EffectiveSort get the sort keys really used by the query
+// item_id is always the last key so the order is total and stable between pages
+func EffectiveSort(keys []SortKey) []SortKey {
+	if len(keys) == 0 {
+		return defaultSort
+	}
+	effective := append([]SortKey{}, keys...)
+	for _, key := range keys {
+		if key.Column == "item_id" {
+			return effective
+		}
+	}
+	return append(effective, SortKey{Column: "item_id"})
+}
+
+// SortSignature describe the sort keys, e.g. "priority,-due_at,item_id"
+func SortSignature(keys []SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.Column
+		if key.Desc {
+			parts[i] = "-" + key.Column
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// SortValues get the values of the sort keys of the item, as stored in the database
+func SortValues(item model.Item, keys []SortKey) []any {
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		switch key.Column {
+		case "item_id":
+			values[i] = item.ItemId
+		case "title":
+			values[i] = item.Title
+		case "status":
+			values[i] = item.Status
+		case "priority":
+			values[i] = int(item.Priority)
+		case "position":
+			values[i] = item.Position
+		case "due_at":
+			values[i] = fromRFC3339(item.DueAt)
+		case "start_at":
+			values[i] = fromRFC3339(item.StartAt)
+		case "created_at":
+			values[i] = item.CreatedAt
+		case "updated_at":
+			values[i] = item.UpdatedAt
+		case "completed_at":
+			values[i] = fromRFC3339(item.CompletedAt)
+		case "archived_at":
+			values[i] = fromRFC3339(item.ArchivedAt)
+		case "deleted_at":
+			values[i] = fromRFC3339(item.DeletedAt)
+		}
+	}
+	return values
+}
+
+// fromRFC3339 convert a date of the item back to the UTC database layout, nil when empty
+func fromRFC3339(value *string) any {
+	if value == nil {
+		return nil
+	}
+	parsed, parseErr := time.Parse(time.RFC3339, *value)
+	if parseErr != nil {
+		return *value
+	}
+	return parsed.UTC().Format(model.DatabaseTimeLayout)
+}
+
+// sortTerms expand the effective sort keys to the ORDER BY expressions
+func sortTerms(keys []SortKey) []sortTerm {
+	terms := []sortTerm{}
+	for i, key := range keys {
+		if nullableColumns[key.Column] {
+			terms = append(terms, sortTerm{expr: key.Column + " IS NULL", key: i, isNull: true})
+		}
+		terms = append(terms, sortTerm{expr: key.Column, desc: key.Desc, key: i})
+	}
+	return terms
+}
+
+// orderBy build the ORDER BY clause of the sort keys, reversed for backward pages
+func orderBy(keys []SortKey, reverse bool) string {
+	clauses := []string{}
+	for _, term := range sortTerms(EffectiveSort(keys)) {
+		direction := "ASC"
+		if term.desc != reverse {
+			direction = "DESC"
+		}
+		clauses = append(clauses, term.expr+" "+direction)
+	}
+	return strings.Join(clauses, ", ")
+}
+
+// keysetCondition build the condition selecting the rows after the keyset,
+// or before it for backward pages:
+// (t1 > v1) or (t1 = v1 and t2 > v2) or ...
+func keysetCondition(keys []SortKey, keyset Keyset) (string, []any, error) {
+	effective := EffectiveSort(keys)
+	if len(keyset.Values) != len(effective) {
+		return "", nil, fmt.Errorf("the cursor does not match the sort")
+	}
+	clauses := []string{}
+	args := []any{}
+	equals := []string{}
+	equalArgs := []any{}
+	for _, term := range sortTerms(effective) {
+		value := keyset.Values[term.key]
+		if term.isNull {
+			value = 0
+			if keyset.Values[term.key] == nil {
+				value = 1
+			}
+		}
+		operator := ">"
+		if term.desc != keyset.Backward {
+			operator = "<"
+		}
+		// Nothing is strictly before or after NULL, the NULL rows only differ by the next terms
+		if value != nil {
+			clause := append(append([]string{}, equals...), "("+term.expr+") "+operator+" ?")
+			clauses = append(clauses, "("+strings.Join(clause, " and ")+")")
+			args = append(append(args, equalArgs...), value)
+		}
+		if value == nil {
+			equals = append(equals, term.expr+" IS NULL")
+		} else {
+			equals = append(equals, "("+term.expr+") = ?")
+			equalArgs = append(equalArgs, value)
+		}
+	}
+	if len(clauses) == 0 {
+		return "FALSE", nil, nil
+	}
+	return "(" + strings.Join(clauses, " or ") + ")", args, nil
+}
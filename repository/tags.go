@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/daniel-vuky/golang-todo-list-v2/model"
+	"github.com/go-sql-driver/mysql"
+)
+
+// mysqlDuplicateEntry error number of a unique key violation
+const mysqlDuplicateEntry uint16 = 1062
+
+const tagColumns string = "t.tag_id, t.user_id, t.name, COUNT(it.item_id), t.created_at, t.updated_at"
+
+// tagUsageJoin join the items using the tags, the items in the trash are not counted
+const tagUsageJoin string = " FROM tags t LEFT JOIN (item_tags it JOIN items i ON i.item_id = it.item_id and i.deleted_at IS NULL) ON it.tag_id = t.tag_id"
+
+type TagsRepository struct {
+	Db *sql.DB
+}
+
+// IsDuplicateError check if the error is a unique key violation
+func IsDuplicateError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+// Insert method of TagsRepository
+// @param tag
+// @throw error
+func (tagsRepository TagsRepository) Insert(tag *model.Tag) error {
+	result, err := tagsRepository.Db.Exec(
+		"INSERT INTO tags (user_id, name) values (?, ?)",
+		tag.UserId,
+		tag.Name,
+	)
+	if err != nil {
+		return err
+	}
+	lastInsertId, insertErr := result.LastInsertId()
+	if insertErr != nil {
+		return insertErr
+	}
+	tag.TagId = uint64(lastInsertId)
+	return nil
+}
+
+// Find method of TagsRepository
+// @param id
+// @param userID
+// @return tag with usage count
+// @throw error
+func (tagsRepository TagsRepository) Find(id uint64, userID uint64) (model.Tag, error) {
+	var tag model.Tag
+	exec := "SELECT " + tagColumns + tagUsageJoin +
+		" WHERE t.tag_id = ? and t.user_id = ? GROUP BY t.tag_id"
+	queryErr := tagsRepository.Db.QueryRow(exec, id, userID).Scan(
+		&tag.TagId,
+		&tag.UserId,
+		&tag.Name,
+		&tag.UsageCount,
+		&tag.CreatedAt,
+		&tag.UpdatedAt,
+	)
+	if queryErr != nil {
+		return model.Tag{}, queryErr
+	}
+
+	return tag, nil
+}
+
+// FindAll method of TagsRepository
+// @param userID
+// @return tags with usage counts
+// @throw error
+func (tagsRepository TagsRepository) FindAll(userID uint64) ([]model.Tag, error) {
+	rows, err := tagsRepository.Db.Query(
+		"SELECT "+tagColumns+tagUsageJoin+
+			" WHERE t.user_id = ? GROUP BY t.tag_id order by t.name",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []model.Tag{}
+	for rows.Next() {
+		var tag model.Tag
+		if scanErr := rows.Scan(
+			&tag.TagId,
+			&tag.UserId,
+			&tag.Name,
+			&tag.UsageCount,
+			&tag.CreatedAt,
+			&tag.UpdatedAt,
+		); scanErr != nil {
+			return nil, scanErr
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// Update method of TagsRepository
+// The version of the tagged items is bumped, their entity tags change with the name of the tag
+// @param tag
+// @param tagInput
+// @throw error
+func (tagsRepository TagsRepository) Update(tag *model.Tag, tagInput *model.TagInput) error {
+	return tagsRepository.changeTaggedItems(tag.TagId, "UPDATE tags set name = ? WHERE tag_id = ?", tagInput.Name, tag.TagId)
+}
+
+// Delete method of TagsRepository
+// The tag is removed from all the items, their version is bumped
+// @param tagId
+// @throw error
+func (tagsRepository TagsRepository) Delete(tagId uint64) error {
+	return tagsRepository.changeTaggedItems(tagId, "DELETE FROM tags WHERE tag_id = ?", tagId)
+}
+
+// changeTaggedItems bump the version of the items of the tag and run the change of the tag, in one transaction
+// The items are bumped first, the delete of the tag removes it from the items
+func (tagsRepository TagsRepository) changeTaggedItems(tagId uint64, exec string, args ...any) error {
+	tx, txErr := tagsRepository.Db.Begin()
+	if txErr != nil {
+		return txErr
+	}
+	defer tx.Rollback()
+
+	_, bumpedErr := tx.Exec(
+		"UPDATE items set version = version + 1 WHERE item_id IN (SELECT item_id FROM item_tags WHERE tag_id = ?)",
+		tagId,
+	)
+	if bumpedErr != nil {
+		return bumpedErr
+	}
+	if _, changedErr := tx.Exec(exec, args...); changedErr != nil {
+		return changedErr
+	}
+	return tx.Commit()
+}
@@ -30,13 +30,69 @@ func (usersRepository UsersRepository) CreateNewUser(user *model.User) error {
 
 // GetUser get existed user
 func (usersRepository UsersRepository) GetUser(user *model.User) error {
-	exec := "SELECT user_id, username, email, password FROM users WHERE username = ?"
+	exec := "SELECT user_id, username, email, password, token_version FROM users WHERE username = ?"
 	queryErr := usersRepository.Db.QueryRow(exec, user.Username).Scan(
 		&user.UserId,
 		&user.Username,
 		&user.Email,
 		&user.Password,
+		&user.TokenVersion,
 	)
 
 	return queryErr
 }
+
+// GetTimezone get the timezone of the user
+func (usersRepository UsersRepository) GetTimezone(userID uint64) (string, error) {
+	var timezone string
+	queryErr := usersRepository.Db.QueryRow(
+		"SELECT timezone FROM users WHERE user_id = ?",
+		userID,
+	).Scan(&timezone)
+
+	return timezone, queryErr
+}
+
+// UpdateTimezone change the timezone of the user
+func (usersRepository UsersRepository) UpdateTimezone(userID uint64, timezone string) error {
+	_, updatedErr := usersRepository.Db.Exec(
+		"UPDATE users set timezone = ? WHERE user_id = ?",
+		timezone,
+		userID,
+	)
+
+	return updatedErr
+}
+
+// FindByLogin find the user by username or email
+func (usersRepository UsersRepository) FindByLogin(login string) (model.User, error) {
+	var user model.User
+	queryErr := usersRepository.Db.QueryRow(
+		"SELECT user_id, username, email FROM users WHERE username = ? or email = ? LIMIT 1",
+		login,
+		login,
+	).Scan(&user.UserId, &user.Username, &user.Email)
+
+	return user, queryErr
+}
+
+// FindByID find the user by ID
+func (usersRepository UsersRepository) FindByID(userID uint64) (model.User, error) {
+	var user model.User
+	queryErr := usersRepository.Db.QueryRow(
+		"SELECT user_id, username, email, token_version FROM users WHERE user_id = ?",
+		userID,
+	).Scan(&user.UserId, &user.Username, &user.Email, &user.TokenVersion)
+
+	return user, queryErr
+}
+
+// IncrementTokenVersion refuse all the access tokens issued to the user until now
+func (usersRepository UsersRepository) IncrementTokenVersion(userID uint64) error {
+	_, updatedErr := usersRepository.Db.Exec(
+		"UPDATE users set token_version = token_version + 1 WHERE user_id = ?",
+		userID,
+	)
+
+	return updatedErr
+}
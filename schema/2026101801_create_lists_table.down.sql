@@ -0,0 +1,6 @@
+-- Drop list_id from items
+ALTER TABLE items DROP FOREIGN KEY fk_items_list_id;
+ALTER TABLE items DROP COLUMN list_id;
+
+-- Drop table lists
+Drop table lists
@@ -0,0 +1,13 @@
+-- Create lists table
+Create TABLE lists (
+   list_id int PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   user_id int NOT NULL,
+   name varchar(255) NOT NULL,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   updated_at datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+   FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
+
+-- Group items into lists
+ALTER TABLE items ADD COLUMN list_id int NULL AFTER user_id;
+ALTER TABLE items ADD CONSTRAINT fk_items_list_id FOREIGN KEY (list_id) REFERENCES lists (list_id) ON DELETE SET NULL;
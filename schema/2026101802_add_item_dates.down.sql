@@ -0,0 +1,7 @@
+-- Drop timezone from users
+ALTER TABLE users DROP COLUMN timezone;
+
+-- Drop due and start dates from items
+DROP INDEX idx_items_user_due_at ON items;
+ALTER TABLE items DROP COLUMN start_at;
+ALTER TABLE items DROP COLUMN due_at;
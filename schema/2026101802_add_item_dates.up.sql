@@ -0,0 +1,7 @@
+-- Add due and start dates to items, stored in UTC
+ALTER TABLE items ADD COLUMN due_at datetime NULL AFTER status;
+ALTER TABLE items ADD COLUMN start_at datetime NULL AFTER due_at;
+CREATE INDEX idx_items_user_due_at ON items (user_id, due_at);
+
+-- Timezone used to compute the today, upcoming and overdue views
+ALTER TABLE users ADD COLUMN timezone varchar(64) NOT NULL DEFAULT 'UTC' AFTER password;
@@ -0,0 +1,5 @@
+-- Drop table item_tags
+Drop table item_tags;
+
+-- Drop table tags
+Drop table tags
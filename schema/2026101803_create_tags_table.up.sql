@@ -0,0 +1,19 @@
+-- Create tags table
+Create TABLE tags (
+   tag_id int PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   user_id int NOT NULL,
+   name varchar(64) NOT NULL,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   updated_at datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+   UNIQUE KEY uq_tags_user_name (user_id, name),
+   FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
+
+-- Create item_tags table
+Create TABLE item_tags (
+   item_id int NOT NULL,
+   tag_id int NOT NULL,
+   PRIMARY KEY (item_id, tag_id),
+   FOREIGN KEY (item_id) REFERENCES items (item_id) ON DELETE CASCADE,
+   FOREIGN KEY (tag_id) REFERENCES tags (tag_id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Drop parent_id from items
+ALTER TABLE items DROP FOREIGN KEY fk_items_parent_id;
+ALTER TABLE items DROP COLUMN parent_id;
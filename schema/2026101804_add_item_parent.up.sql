@@ -0,0 +1,3 @@
+-- Nest items under a parent item, children are removed or re-parented by the application
+ALTER TABLE items ADD COLUMN parent_id int NULL AFTER list_id;
+ALTER TABLE items ADD CONSTRAINT fk_items_parent_id FOREIGN KEY (parent_id) REFERENCES items (item_id);
@@ -0,0 +1,2 @@
+-- Drop recurrence from items
+ALTER TABLE items DROP COLUMN recurrence;
@@ -0,0 +1,2 @@
+-- RFC 5545 recurrence of the item, DTSTART line followed by the RRULE line
+ALTER TABLE items ADD COLUMN recurrence varchar(512) NULL AFTER start_at;
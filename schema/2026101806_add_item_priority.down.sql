@@ -0,0 +1,2 @@
+-- Drop priority from items
+ALTER TABLE items DROP COLUMN priority;
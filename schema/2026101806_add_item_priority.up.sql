@@ -0,0 +1,2 @@
+-- Priority of the item: 0 none, 1 low, 2 medium, 3 high, 4 urgent
+ALTER TABLE items ADD COLUMN priority tinyint NOT NULL DEFAULT 0 AFTER status;
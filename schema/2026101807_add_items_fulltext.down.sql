@@ -0,0 +1,2 @@
+-- Drop full-text index of items
+ALTER TABLE items DROP INDEX ft_items_title_description;
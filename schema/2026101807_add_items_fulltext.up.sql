@@ -0,0 +1,2 @@
+-- Full-text index for the item search
+ALTER TABLE items ADD FULLTEXT INDEX ft_items_title_description (title, description);
@@ -0,0 +1,3 @@
+-- Drop position from items
+ALTER TABLE items DROP INDEX idx_items_user_position;
+ALTER TABLE items DROP COLUMN position;
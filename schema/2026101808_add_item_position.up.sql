@@ -0,0 +1,5 @@
+-- Manual order of the items, rank keys compared byte by byte
+ALTER TABLE items ADD COLUMN position varchar(255) CHARACTER SET ascii COLLATE ascii_bin NOT NULL DEFAULT '' AFTER priority;
+-- Keep the current order of the existing items
+UPDATE items SET position = LPAD(CONV(item_id, 10, 36), 10, '0');
+ALTER TABLE items ADD INDEX idx_items_user_position (user_id, position);
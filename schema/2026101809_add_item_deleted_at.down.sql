@@ -0,0 +1,3 @@
+-- Drop deleted_at from items, the items in the trash come back
+ALTER TABLE items DROP INDEX idx_items_user_deleted_at;
+ALTER TABLE items DROP COLUMN deleted_at;
@@ -0,0 +1,3 @@
+-- Items in the trash, stored in UTC, NULL when the item is not deleted
+ALTER TABLE items ADD COLUMN deleted_at datetime NULL AFTER updated_at;
+ALTER TABLE items ADD INDEX idx_items_user_deleted_at (user_id, deleted_at);
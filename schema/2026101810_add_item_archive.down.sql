@@ -0,0 +1,4 @@
+-- Drop completion and archive dates from items
+ALTER TABLE items DROP INDEX idx_items_status_completed_at;
+ALTER TABLE items DROP COLUMN archived_at;
+ALTER TABLE items DROP COLUMN completed_at;
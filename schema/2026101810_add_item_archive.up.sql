@@ -0,0 +1,6 @@
+-- Completion and archive dates of the items, stored in UTC
+ALTER TABLE items ADD COLUMN completed_at datetime NULL AFTER recurrence;
+ALTER TABLE items ADD COLUMN archived_at datetime NULL AFTER completed_at;
+-- The real completion date of the existing items is unknown, use their last update
+UPDATE items SET completed_at = updated_at WHERE status = 2;
+ALTER TABLE items ADD INDEX idx_items_status_completed_at (status, completed_at);
@@ -0,0 +1,2 @@
+-- Drop table item_history
+Drop table item_history;
@@ -0,0 +1,16 @@
+-- Create item_history table, append-only history of the changes of the items
+-- item_id has no foreign key so the history outlives the items deleted for good
+Create TABLE item_history (
+   history_id bigint PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   item_id int NOT NULL,
+   user_id int NOT NULL,
+   version int NOT NULL,
+   action varchar(16) NOT NULL,
+   actor_id int NULL,
+   request_id varchar(64) NULL,
+   changes json NOT NULL,
+   snapshot json NOT NULL,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   UNIQUE KEY uq_item_history_version (item_id, version),
+   FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
@@ -0,0 +1,2 @@
+-- Drop table comments
+Drop table comments;
@@ -0,0 +1,12 @@
+-- Create comments table, the body is Markdown
+Create TABLE comments (
+   comment_id int PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   item_id int NOT NULL,
+   user_id int NOT NULL,
+   body text NOT NULL,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   updated_at datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+   INDEX idx_comments_item (item_id, comment_id),
+   FOREIGN KEY (item_id) REFERENCES items (item_id) ON DELETE CASCADE,
+   FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
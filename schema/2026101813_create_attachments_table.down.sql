@@ -0,0 +1,2 @@
+-- Drop table attachments
+Drop table attachments;
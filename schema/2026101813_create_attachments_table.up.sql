@@ -0,0 +1,15 @@
+-- Create attachments table, the content of the files is kept in the blob store
+-- item_id is cleared when the item is deleted, the blobs of these attachments are purged afterwards
+Create TABLE attachments (
+   attachment_id int PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   item_id int,
+   user_id int NOT NULL,
+   filename varchar(255) NOT NULL,
+   content_type varchar(255) NOT NULL,
+   size bigint NOT NULL,
+   storage_key varchar(255) NOT NULL,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   INDEX idx_attachments_item (item_id, attachment_id),
+   FOREIGN KEY (item_id) REFERENCES items (item_id) ON DELETE SET NULL,
+   FOREIGN KEY (user_id) REFERENCES users (user_id)
+);
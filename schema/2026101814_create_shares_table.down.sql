@@ -0,0 +1,2 @@
+-- Drop table shares
+Drop table shares;
@@ -0,0 +1,23 @@
+-- Create shares table, a grant of a role on an item or on a list to another user
+-- Exactly one of item_id and list_id is set
+-- accepted_at is empty until the invited user accepts the grant
+Create TABLE shares (
+   share_id int PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   item_id int,
+   list_id int,
+   owner_id int NOT NULL,
+   grantee_id int NOT NULL,
+   role ENUM('viewer', 'editor', 'owner') NOT NULL DEFAULT 'viewer',
+   invited_by int NOT NULL,
+   accepted_at datetime,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   updated_at datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+   UNIQUE KEY uq_shares_item_grantee (item_id, grantee_id),
+   UNIQUE KEY uq_shares_list_grantee (list_id, grantee_id),
+   INDEX idx_shares_grantee (grantee_id, accepted_at),
+   FOREIGN KEY (item_id) REFERENCES items (item_id) ON DELETE CASCADE,
+   FOREIGN KEY (list_id) REFERENCES lists (list_id) ON DELETE CASCADE,
+   FOREIGN KEY (owner_id) REFERENCES users (user_id),
+   FOREIGN KEY (grantee_id) REFERENCES users (user_id),
+   FOREIGN KEY (invited_by) REFERENCES users (user_id)
+);
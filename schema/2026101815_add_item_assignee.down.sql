@@ -0,0 +1,5 @@
+-- Drop table notifications and assignee_id from items
+Drop table notifications;
+ALTER TABLE items DROP FOREIGN KEY fk_items_assignee_id;
+ALTER TABLE items DROP INDEX idx_items_assignee;
+ALTER TABLE items DROP COLUMN assignee_id;
@@ -0,0 +1,18 @@
+-- Assign items to another user, the assignee can see and change the item
+ALTER TABLE items ADD COLUMN assignee_id int NULL AFTER user_id;
+ALTER TABLE items ADD CONSTRAINT fk_items_assignee_id FOREIGN KEY (assignee_id) REFERENCES users (user_id) ON DELETE SET NULL;
+ALTER TABLE items ADD INDEX idx_items_assignee (assignee_id);
+-- Create notifications table, the events sent to the users like the changes of the assignee of an item
+Create TABLE notifications (
+   notification_id bigint PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   user_id int NOT NULL,
+   item_id int,
+   actor_id int,
+   type varchar(32) NOT NULL,
+   payload json NOT NULL,
+   read_at datetime,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   INDEX idx_notifications_user (user_id, notification_id),
+   FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE,
+   FOREIGN KEY (item_id) REFERENCES items (item_id) ON DELETE SET NULL
+);
@@ -0,0 +1,2 @@
+-- Drop version from items
+ALTER TABLE items DROP COLUMN version;
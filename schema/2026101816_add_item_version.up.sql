@@ -0,0 +1,2 @@
+-- Version of the items, increased on every change and used by the If-Match precondition
+ALTER TABLE items ADD COLUMN version int NOT NULL DEFAULT 1 AFTER position;
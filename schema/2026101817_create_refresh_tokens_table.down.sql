@@ -0,0 +1,2 @@
+-- Drop table refresh_tokens
+Drop table refresh_tokens;
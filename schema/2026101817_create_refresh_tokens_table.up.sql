@@ -0,0 +1,16 @@
+-- Create refresh_tokens table, only the SHA-256 hash of the tokens is stored
+-- A token is used once, its successor is in the same family, reusing a token revokes its family
+Create TABLE refresh_tokens (
+   refresh_token_id bigint PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   user_id int NOT NULL,
+   family_id char(32) NOT NULL,
+   token_hash char(64) NOT NULL,
+   expires_at datetime NOT NULL,
+   used_at datetime,
+   revoked_at datetime,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   UNIQUE KEY uq_refresh_tokens_hash (token_hash),
+   INDEX idx_refresh_tokens_family (family_id),
+   INDEX idx_refresh_tokens_expires_at (expires_at),
+   FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
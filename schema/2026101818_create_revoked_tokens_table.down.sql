@@ -0,0 +1,3 @@
+-- Drop table revoked_tokens and token_version from users
+Drop table revoked_tokens;
+ALTER TABLE users DROP COLUMN token_version;
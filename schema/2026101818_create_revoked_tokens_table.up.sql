@@ -0,0 +1,12 @@
+-- Version of the tokens of the users, the tokens of an older version are refused
+ALTER TABLE users ADD COLUMN token_version int NOT NULL DEFAULT 0;
+-- Create revoked_tokens table, the access tokens revoked before their expiry by their jti claim
+-- The rows are purged once the tokens are expired
+Create TABLE revoked_tokens (
+   jti char(32) PRIMARY KEY NOT NULL,
+   user_id int NOT NULL,
+   expires_at datetime NOT NULL,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   INDEX idx_revoked_tokens_expires_at (expires_at),
+   FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
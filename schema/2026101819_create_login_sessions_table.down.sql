@@ -0,0 +1,2 @@
+-- Drop table login_sessions
+Drop table login_sessions;
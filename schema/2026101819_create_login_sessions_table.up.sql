@@ -0,0 +1,15 @@
+-- Create login_sessions table, the devices the users are logged in from
+-- A login session follows one family of refresh tokens, the access tokens carry its family in the sid claim
+Create TABLE login_sessions (
+   login_session_id bigint PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   user_id int NOT NULL,
+   family_id char(32) NOT NULL,
+   user_agent varchar(255) NOT NULL DEFAULT '',
+   ip_address varchar(45) NOT NULL DEFAULT '',
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   last_seen_at datetime DEFAULT CURRENT_TIMESTAMP,
+   revoked_at datetime,
+   UNIQUE KEY uq_login_sessions_family (family_id),
+   INDEX idx_login_sessions_user (user_id),
+   FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- Drop table personal_access_tokens
+Drop table personal_access_tokens;
@@ -0,0 +1,16 @@
+-- Create personal_access_tokens table, the named long-lived tokens of the scripts of the users
+-- Only the SHA-256 hash of the tokens is stored, the scopes are separated by commas
+Create TABLE personal_access_tokens (
+   personal_access_token_id bigint PRIMARY KEY AUTO_INCREMENT NOT NULL,
+   user_id int NOT NULL,
+   name varchar(255) NOT NULL,
+   token_hash char(64) NOT NULL,
+   scopes varchar(255) NOT NULL,
+   expires_at datetime,
+   last_used_at datetime,
+   revoked_at datetime,
+   created_at datetime DEFAULT CURRENT_TIMESTAMP,
+   UNIQUE KEY uq_personal_access_tokens_hash (token_hash),
+   INDEX idx_personal_access_tokens_user (user_id),
+   FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE
+);
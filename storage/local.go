@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keep the blobs as files under Dir
+type LocalStore struct {
+	Dir string
+}
+
+// path get the file of the key, the key can not leave Dir
+func (localStore LocalStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if key == "" || cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(localStore.Dir, filepath.FromSlash(cleaned)), nil
+}
+
+// Put write the blob to a temporary file renamed once complete,
+// so a failed upload never leaves a partial blob behind
+func (localStore LocalStore) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	path, pathErr := localStore.path(key)
+	if pathErr != nil {
+		return pathErr
+	}
+	if mkdirErr := os.MkdirAll(filepath.Dir(path), 0o750); mkdirErr != nil {
+		return mkdirErr
+	}
+	file, createErr := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if createErr != nil {
+		return createErr
+	}
+	defer os.Remove(file.Name())
+
+	if _, copyErr := io.Copy(file, body); copyErr != nil {
+		file.Close()
+		return copyErr
+	}
+	if closeErr := file.Close(); closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Get open the file of the blob
+func (localStore LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, pathErr := localStore.path(key)
+	if pathErr != nil {
+		return nil, pathErr
+	}
+	file, openErr := os.Open(path)
+	if errors.Is(openErr, fs.ErrNotExist) {
+		return nil, ErrBlobNotFound
+	}
+	return file, openErr
+}
+
+// Delete remove the file of the blob
+func (localStore LocalStore) Delete(ctx context.Context, key string) error {
+	path, pathErr := localStore.path(key)
+	if pathErr != nil {
+		return pathErr
+	}
+	if removeErr := os.Remove(path); removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+		return removeErr
+	}
+	return nil
+}
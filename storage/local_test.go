@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStoreRoundTrip(t *testing.T) {
+	store := LocalStore{Dir: t.TempDir()}
+	ctx := context.Background()
+	key := "items/42/notes.txt"
+	content := "the notes"
+
+	if err := store.Put(ctx, key, strings.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(blob)
+	blob.Close()
+	if string(got) != content {
+		t.Errorf("Get = %q, want %q", got, content)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrBlobNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing key = %v, want nil", err)
+	}
+}
+
+func TestLocalStoreFailedPutLeavesNoBlob(t *testing.T) {
+	dir := t.TempDir()
+	store := LocalStore{Dir: dir}
+	failing := io.MultiReader(strings.NewReader("partial"), errorReader{})
+
+	if err := store.Put(context.Background(), "broken.txt", failing, 100, "text/plain"); err == nil {
+		t.Fatal("Put of a failing body = nil, want its error")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Put left %d files behind", len(entries))
+	}
+}
+
+func TestLocalStoreKeyStaysInDir(t *testing.T) {
+	dir := t.TempDir()
+	store := LocalStore{Dir: filepath.Join(dir, "blobs")}
+	for _, key := range []string{"", "/", "../outside.txt", "items/../../outside.txt"} {
+		if err := store.Put(context.Background(), key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) = nil, want an invalid key error", key)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outside.txt")); !os.IsNotExist(err) {
+		t.Errorf("a blob was written outside of the directory")
+	}
+}
+
+type errorReader struct{}
+
+func (errorReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const s3TimeLayout string = "20060102T150405Z"
+const s3DateLayout string = "20060102"
+const s3SignedHeaders string = "host;x-amz-content-sha256;x-amz-date"
+
+// S3Store keep the blobs in a bucket of an S3 compatible server, AWS or a MinIO like server
+// The bucket is addressed in the path, http://endpoint/bucket/key, and must already exist
+// The requests are signed with AWS Signature Version 4
+type S3Store struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Client    *http.Client
+}
+
+// Put upload the blob, it is read in memory to sign its content
+func (s3Store S3Store) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	content, readErr := io.ReadAll(body)
+	if readErr != nil {
+		return readErr
+	}
+	request, requestErr := s3Store.newRequest(ctx, http.MethodPut, key, content)
+	if requestErr != nil {
+		return requestErr
+	}
+	request.ContentLength = int64(len(content))
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	response, responseErr := s3Store.do(request)
+	if responseErr != nil {
+		return responseErr
+	}
+	return response.Body.Close()
+}
+
+// Get download the blob, the caller must close it
+func (s3Store S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	request, requestErr := s3Store.newRequest(ctx, http.MethodGet, key, nil)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+	response, responseErr := s3Store.do(request)
+	if responseErr != nil {
+		return nil, responseErr
+	}
+	return response.Body, nil
+}
+
+// Delete remove the blob, S3 answers the same for a missing key
+func (s3Store S3Store) Delete(ctx context.Context, key string) error {
+	request, requestErr := s3Store.newRequest(ctx, http.MethodDelete, key, nil)
+	if requestErr != nil {
+		return requestErr
+	}
+	response, responseErr := s3Store.do(request)
+	if responseErr == ErrBlobNotFound {
+		return nil
+	}
+	if responseErr != nil {
+		return responseErr
+	}
+	return response.Body.Close()
+}
+
+// newRequest create the signed request of the key
+func (s3Store S3Store) newRequest(ctx context.Context, method, key string, content []byte) (*http.Request, error) {
+	endpoint, parseErr := url.Parse(strings.TrimRight(s3Store.Endpoint, "/"))
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	endpoint.Path += "/" + s3Store.Bucket + "/" + strings.TrimLeft(key, "/")
+	endpoint.RawPath = encodePath(endpoint.Path)
+
+	request, requestErr := http.NewRequestWithContext(ctx, method, endpoint.String(), bytes.NewReader(content))
+	if requestErr != nil {
+		return nil, requestErr
+	}
+	s3Store.sign(request, content, time.Now().UTC())
+	return request, nil
+}
+
+// do send the request, the errors of the server are read from the response
+func (s3Store S3Store) do(request *http.Request) (*http.Response, error) {
+	client := s3Store.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return response, nil
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusNotFound {
+		return nil, ErrBlobNotFound
+	}
+	message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+	return nil, fmt.Errorf("s3 %s %s failed with status %d, %s", request.Method, request.URL.Path, response.StatusCode, message)
+}
+
+// sign add the AWS Signature Version 4 headers to the request
+func (s3Store S3Store) sign(request *http.Request, content []byte, now time.Time) {
+	payloadHash := sha256Hex(content)
+	amzDate := now.Format(s3TimeLayout)
+	scope := now.Format(s3DateLayout) + "/" + s3Store.Region + "/s3/aws4_request"
+	request.Header.Set("X-Amz-Date", amzDate)
+	request.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.RawQuery,
+		"host:" + request.URL.Host + "\n" +
+			"x-amz-content-sha256:" + payloadHash + "\n" +
+			"x-amz-date:" + amzDate + "\n",
+		s3SignedHeaders,
+		payloadHash,
+	}, "\n")
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s3Store.SecretKey), now.Format(s3DateLayout))
+	for _, part := range []string{s3Store.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Store.AccessKey,
+		scope,
+		s3SignedHeaders,
+		hex.EncodeToString(hmacSHA256(signingKey, stringToSign)),
+	))
+}
+
+// encodePath escape the path as S3 expects it, every byte but the unreserved ones and the slashes
+func encodePath(path string) string {
+	var encoded strings.Builder
+	for _, b := range []byte(path) {
+		if b >= 'A' && b <= 'Z' || b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || strings.IndexByte("-._~/", b) >= 0 {
+			encoded.WriteByte(b)
+			continue
+		}
+		fmt.Fprintf(&encoded, "%%%02X", b)
+	}
+	return encoded.String()
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testAccessKey string = "minio-access"
+const testSecretKey string = "minio-secret"
+const testRegion string = "us-east-1"
+const testBucket string = "attachments"
+
+// fakeBucket stand-in of a MinIO like server, it checks the SigV4 headers the way S3 does
+type fakeBucket struct {
+	t       *testing.T
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeBucket(t *testing.T) (*fakeBucket, *httptest.Server) {
+	bucket := &fakeBucket{t: t, objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(bucket)
+	t.Cleanup(server.Close)
+	return bucket, server
+}
+
+func (bucket *fakeBucket) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := bucket.verify(r, body); err != nil {
+		bucket.t.Errorf("%s %s: %v", r.Method, r.URL.EscapedPath(), err)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+	prefix := "/" + testBucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		http.Error(w, "NoSuchBucket", http.StatusNotFound)
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		bucket.objects[key] = body
+		bucket.types[key] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		object, existed := bucket.objects[key]
+		if !existed {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write(object)
+	case http.MethodDelete:
+		delete(bucket.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "MethodNotAllowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recompute the signature of the request from what the server received
+func (bucket *fakeBucket) verify(r *http.Request, body []byte) error {
+	payloadHash := sha256.Sum256(body)
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != hex.EncodeToString(payloadHash[:]) {
+		return fmt.Errorf("X-Amz-Content-Sha256 = %q, not the hash of the body", got)
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, parseErr := time.Parse("20060102T150405Z", amzDate)
+	if parseErr != nil {
+		return fmt.Errorf("X-Amz-Date = %q: %v", amzDate, parseErr)
+	}
+	if skew := time.Since(signedAt); skew > time.Minute || skew < -time.Minute {
+		return fmt.Errorf("X-Amz-Date = %q is too far from now", amzDate)
+	}
+	date := signedAt.Format("20060102")
+	scope := date + "/" + testRegion + "/s3/aws4_request"
+	prefix := "AWS4-HMAC-SHA256 Credential=" + testAccessKey + "/" + scope + ", SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, prefix) {
+		return fmt.Errorf("Authorization = %q, want the prefix %q", authorization, prefix)
+	}
+
+	canonicalRequest := r.Method + "\n" +
+		r.URL.EscapedPath() + "\n" +
+		r.URL.RawQuery + "\n" +
+		"host:" + r.Host + "\n" +
+		"x-amz-content-sha256:" + r.Header.Get("X-Amz-Content-Sha256") + "\n" +
+		"x-amz-date:" + amzDate + "\n\n" +
+		"host;x-amz-content-sha256;x-amz-date\n" +
+		r.Header.Get("X-Amz-Content-Sha256")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+	key := []byte("AWS4" + testSecretKey)
+	for _, part := range []string{date, testRegion, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	if signature := strings.TrimPrefix(authorization, prefix); signature != hex.EncodeToString(key) {
+		return fmt.Errorf("Signature = %q, want %q", signature, hex.EncodeToString(key))
+	}
+	return nil
+}
+
+func newTestS3Store(server *httptest.Server) S3Store {
+	return S3Store{
+		Endpoint:  server.URL,
+		Region:    testRegion,
+		Bucket:    testBucket,
+		AccessKey: testAccessKey,
+		SecretKey: testSecretKey,
+		Client:    server.Client(),
+	}
+}
+
+func TestS3StoreRoundTrip(t *testing.T) {
+	bucket, server := newFakeBucket(t)
+	store := newTestS3Store(server)
+	ctx := context.Background()
+	key := "items/42/report final (v2).pdf"
+	content := []byte("%PDF-1.4 the report")
+
+	if err := store.Put(ctx, key, bytes.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := bucket.types[key]; got != "application/pdf" {
+		t.Errorf("Content-Type = %q, want application/pdf", got)
+	}
+
+	blob, err := store.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(blob)
+	blob.Close()
+	if !bytes.Equal(got, content) {
+		t.Errorf("Get = %q, want %q", got, content)
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrBlobNotFound) {
+		t.Errorf("Get after Delete = %v, want ErrBlobNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("Delete of a missing key = %v, want nil", err)
+	}
+}
+
+func TestS3StoreEmptyBody(t *testing.T) {
+	_, server := newFakeBucket(t)
+	store := newTestS3Store(server)
+	if err := store.Put(context.Background(), "empty.txt", bytes.NewReader(nil), 0, ""); err != nil {
+		t.Fatalf("Put of an empty blob: %v", err)
+	}
+}
+
+func TestS3StoreServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer server.Close()
+	store := newTestS3Store(server)
+
+	err := store.Put(context.Background(), "denied.txt", strings.NewReader("content"), 7, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "403") || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Put = %v, want the 403 AccessDenied error of the server", err)
+	}
+}
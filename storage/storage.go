@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// DriverLocal keep the blobs on the local filesystem
+const DriverLocal string = "local"
+
+// DriverS3 keep the blobs in an S3 compatible bucket
+const DriverS3 string = "s3"
+
+// DefaultLocalDir directory of the local blobs when STORAGE_LOCAL_DIR is not set
+const DefaultLocalDir string = "./data/attachments"
+
+// DefaultS3Region region used to sign the requests when S3_REGION is not set
+const DefaultS3Region string = "us-east-1"
+
+// ErrBlobNotFound is returned by Get when there is no blob with the key
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keep the content of the files by key
+// Keys are slash separated paths, Delete of a missing key is not an error
+type BlobStore interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New create the blob store selected by STORAGE_DRIVER, local by default
+func New() (BlobStore, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", DriverLocal:
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = DefaultLocalDir
+		}
+		return LocalStore{Dir: dir}, nil
+	case DriverS3:
+		store := S3Store{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			Client:    http.DefaultClient,
+		}
+		if store.Region == "" {
+			store.Region = DefaultS3Region
+		}
+		if store.Endpoint == "" || store.Bucket == "" {
+			return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required by the s3 storage driver")
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}